@@ -3,33 +3,145 @@
 
 package customconfiguration
 
-import "go.opentelemetry.io/collector/pdata/pcommon"
+import (
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+)
 
 type DropActions struct {
-	Actions []ActionItem
+	Actions    []ActionItem
+	conditions ruleConditions
+	counters   ruleCounters
 }
 
-func NewDropper(rules []Rule) *DropActions {
+func NewDropper(rules []Rule, settings component.TelemetrySettings) *DropActions {
+	actions := generateActionDetails(rules, AllowListActionDrop)
+	ruleNames := make([]string, len(actions))
+	for i, action := range actions {
+		ruleNames[i] = action.RuleName
+	}
 	return &DropActions{
-		Actions: generateActionDetails(rules, AllowListActionDrop),
+		Actions:    actions,
+		conditions: compileRuleConditions(actions, settings),
+		counters:   newRuleCounters(ruleNames, string(AllowListActionDrop), settings),
 	}
 }
 
-func (d *DropActions) ShouldBeDropped(attributes pcommon.Map) (bool, error) {
+// Counters exposes every rule's matched/dropped/sampled-out totals, for wiring into the processor's
+// self-telemetry instruments.
+func (d *DropActions) Counters() *ruleCounters {
+	return &d.counters
+}
+
+func (d *DropActions) ShouldBeDropped(attributes pcommon.Map) (bool, DropReason, error) {
 	// nothing will be dropped if no rule is defined
 	if d.Actions == nil || len(d.Actions) == 0 {
-		return false, nil
+		return false, DropReasonNone, nil
 	}
-	for _, element := range d.Actions {
+	for i, element := range d.Actions {
 		isMatched, err := matchesSelectors(attributes, element.SelectorMatchers, false)
-		if isMatched {
-			// drop the datapoint as one of drop rules is matched
-			return true, nil
-		}
 		if err != nil {
 			// keep the datapoint as an error occurred in match process
-			return false, err
+			return false, DropReasonNone, err
+		}
+		if !isMatched {
+			continue
+		}
+
+		dropped, reason := d.counters.decide(i, element.SamplingPercentage, sampleKeyFromAttributes(attributes, element.SamplingAttributeKey))
+		if dropped {
+			return true, reason, nil
+		}
+	}
+	return false, DropReasonNone, nil
+}
+
+// ShouldBeDroppedSpan is ShouldBeDropped for traces: rules with a compiled OTTL condition are
+// evaluated against the real span/scope/resource rather than just its attribute map, so a
+// condition can reference span kind, status, or name in addition to attributes. Rules without one
+// fall back to ShouldBeDropped's SelectorMatchers path against the span's own attributes. A rule
+// that matches is still subject to its SamplingPercentage before the span is actually dropped.
+func (d *DropActions) ShouldBeDroppedSpan(span ptrace.Span, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, DropReason, error) {
+	for i, element := range d.Actions {
+		matched, err := spanMatchesRule(span, scope, resource, d.conditions.span[i], element)
+		if err != nil {
+			return false, DropReasonNone, err
+		}
+		if !matched {
+			continue
+		}
+
+		dropped, reason := d.counters.decide(i, element.SamplingPercentage, sampleKeyForSpan(span, element.SamplingAttributeKey))
+		if dropped {
+			return true, reason, nil
+		}
+	}
+	return false, DropReasonNone, nil
+}
+
+// ShouldBeDroppedDataPoint is ShouldBeDropped for metrics; see ShouldBeDroppedSpan.
+func (d *DropActions) ShouldBeDroppedDataPoint(dataPoint interface{}, metric pmetric.Metric, attributes pcommon.Map, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, DropReason, error) {
+	for i, element := range d.Actions {
+		matched, err := dataPointMatchesRule(dataPoint, metric, attributes, scope, resource, d.conditions.datapoint[i], element)
+		if err != nil {
+			return false, DropReasonNone, err
 		}
+		if !matched {
+			continue
+		}
+
+		dropped, reason := d.counters.decide(i, element.SamplingPercentage, sampleKeyFromAttributes(attributes, element.SamplingAttributeKey))
+		if dropped {
+			return true, reason, nil
+		}
+	}
+	return false, DropReasonNone, nil
+}
+
+// ShouldBeDroppedLogRecord is ShouldBeDropped for logs; see ShouldBeDroppedSpan.
+func (d *DropActions) ShouldBeDroppedLogRecord(record plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, DropReason, error) {
+	for i, element := range d.Actions {
+		matched, err := logRecordMatchesRule(record, scope, resource, d.conditions.log[i], element)
+		if err != nil {
+			return false, DropReasonNone, err
+		}
+		if !matched {
+			continue
+		}
+
+		dropped, reason := d.counters.decide(i, element.SamplingPercentage, sampleKeyForLogRecord(record, element.SamplingAttributeKey))
+		if dropped {
+			return true, reason, nil
+		}
+	}
+	return false, DropReasonNone, nil
+}
+
+func spanMatchesRule(span ptrace.Span, scope pcommon.InstrumentationScope, resource pcommon.Resource, cond *ottl.Condition[ottlspan.TransformContext], element ActionItem) (bool, error) {
+	if cond != nil {
+		return evalSpanCondition(cond, span, scope, resource)
+	}
+	return matchesSelectors(span.Attributes(), element.SelectorMatchers, false)
+}
+
+func dataPointMatchesRule(dataPoint interface{}, metric pmetric.Metric, attributes pcommon.Map, scope pcommon.InstrumentationScope, resource pcommon.Resource, cond *ottl.Condition[ottldatapoint.TransformContext], element ActionItem) (bool, error) {
+	if cond != nil {
+		return evalDataPointCondition(cond, dataPoint, metric, scope, resource)
+	}
+	return matchesSelectors(attributes, element.SelectorMatchers, false)
+}
+
+func logRecordMatchesRule(record plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource, cond *ottl.Condition[ottllog.TransformContext], element ActionItem) (bool, error) {
+	if cond != nil {
+		return evalLogCondition(cond, record, scope, resource)
 	}
-	return false, nil
+	return matchesSelectors(record.Attributes(), element.SelectorMatchers, false)
 }
@@ -0,0 +1,102 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package customconfiguration
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+)
+
+// ruleConditions holds one compiled OTTL condition per action per signal type, in the same order
+// as the []ActionItem that produced them - NOT the []Rule the caller started from, since e.g.
+// DropActions only carries the drop-type subset of rules and indexes conditions.span[i]/etc. by
+// position in that subset. Compiling against actions keeps every index in these slices aligned
+// with Actions regardless of how many non-matching-type rules sit between two drop rules. An
+// action with no OTTLCondition gets a nil entry in every slice, and one whose condition failed to
+// compile for a context gets a nil entry in that context's slice, which the matcher-based actions (DropActions today; keep/replace actions, once they exist, the
+// same way) treat as "fall back to SelectorMatchers for this action". Compiling once here - rather
+// than per span/datapoint/log record - is what makes OTTL conditions affordable to evaluate on the
+// hot path.
+type ruleConditions struct {
+	span      []*ottl.Condition[ottlspan.TransformContext]
+	datapoint []*ottl.Condition[ottldatapoint.TransformContext]
+	log       []*ottl.Condition[ottllog.TransformContext]
+}
+
+// compileRuleConditions parses every action's OTTLCondition (if any) against all three signal
+// contexts, so whichever ShouldBeDropped* entry point ends up being called for a given action
+// always has a compiled condition ready at the same index into Actions. An action without an
+// OTTLCondition is left to the SelectorMatchers fallback in every context; one whose condition
+// fails to parse for a given context is logged and left to that fallback for that context.
+func compileRuleConditions(actions []ActionItem, settings component.TelemetrySettings) ruleConditions {
+	conditions := ruleConditions{
+		span:      make([]*ottl.Condition[ottlspan.TransformContext], len(actions)),
+		datapoint: make([]*ottl.Condition[ottldatapoint.TransformContext], len(actions)),
+		log:       make([]*ottl.Condition[ottllog.TransformContext], len(actions)),
+	}
+
+	spanParser, spanParserErr := ottlspan.NewParser(ottlfuncs.StandardFuncs[ottlspan.TransformContext](), settings)
+	datapointParser, datapointParserErr := ottldatapoint.NewParser(ottlfuncs.StandardFuncs[ottldatapoint.TransformContext](), settings)
+	logParser, logParserErr := ottllog.NewParser(ottlfuncs.StandardFuncs[ottllog.TransformContext](), settings)
+
+	for i, action := range actions {
+		if action.OTTLCondition == "" {
+			continue
+		}
+
+		if spanParserErr == nil {
+			if cond, err := spanParser.ParseCondition(action.OTTLCondition); err == nil {
+				conditions.span[i] = cond
+			} else {
+				settings.Logger.Warn("failed to compile OTTL span condition, falling back to selector matching",
+					zap.String("condition", action.OTTLCondition), zap.Error(err))
+			}
+		}
+		if datapointParserErr == nil {
+			if cond, err := datapointParser.ParseCondition(action.OTTLCondition); err == nil {
+				conditions.datapoint[i] = cond
+			} else {
+				settings.Logger.Warn("failed to compile OTTL datapoint condition, falling back to selector matching",
+					zap.String("condition", action.OTTLCondition), zap.Error(err))
+			}
+		}
+		if logParserErr == nil {
+			if cond, err := logParser.ParseCondition(action.OTTLCondition); err == nil {
+				conditions.log[i] = cond
+			} else {
+				settings.Logger.Warn("failed to compile OTTL log condition, falling back to selector matching",
+					zap.String("condition", action.OTTLCondition), zap.Error(err))
+			}
+		}
+	}
+
+	return conditions
+}
+
+func evalSpanCondition(cond *ottl.Condition[ottlspan.TransformContext], span ptrace.Span, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, error) {
+	tCtx := ottlspan.NewTransformContext(span, scope, resource)
+	return cond.Eval(context.Background(), tCtx)
+}
+
+func evalDataPointCondition(cond *ottl.Condition[ottldatapoint.TransformContext], dataPoint interface{}, metric pmetric.Metric, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, error) {
+	tCtx := ottldatapoint.NewTransformContext(dataPoint, metric, pmetric.NewMetricSlice(), scope, resource, pmetric.NewScopeMetrics(), pmetric.NewResourceMetrics())
+	return cond.Eval(context.Background(), tCtx)
+}
+
+func evalLogCondition(cond *ottl.Condition[ottllog.TransformContext], record plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) (bool, error) {
+	tCtx := ottllog.NewTransformContext(record, scope, resource, plog.NewScopeLogs(), plog.NewResourceLogs())
+	return cond.Eval(context.Background(), tCtx)
+}
@@ -0,0 +1,195 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package customconfiguration
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DropReason explains why a ShouldBeDropped* call returned the decision it did, so callers (and
+// eventually the processor's self-telemetry) can distinguish "this rule never matched" from "this
+// rule matched but sampling spared it".
+type DropReason string
+
+const (
+	DropReasonNone       DropReason = ""
+	DropReasonDropped    DropReason = "matched"
+	DropReasonSampledOut DropReason = "sampled_out"
+)
+
+// defaultSamplingAttributeKey is used when a Rule sets a SamplingPercentage below 100 but no
+// SamplingAttributeKey: trace_id is the one identifier nearly every signal already carries (or,
+// for spans/logs, exposes natively), and hashing it keeps every span/log record belonging to the
+// same trace consistently all-dropped or all-kept.
+const defaultSamplingAttributeKey = "trace_id"
+
+// meterName identifies this package's self-telemetry instruments in the MeterProvider passed to
+// NewDropper, the same way the resolver package names its own.
+const meterName = "awsappsignals.customconfiguration"
+
+// ruleCounters tracks, per rule (indexed the same way as DropActions.Actions), how many
+// spans/datapoints/log records matched the rule, how many of those were actually dropped, and how
+// many matched but were spared by SamplingPercentage. It's read by the processor's self-telemetry
+// instruments, and safe to read/increment concurrently.
+type ruleCounters struct {
+	matchedTotal    []atomic.Int64
+	droppedTotal    []atomic.Int64
+	sampledOutTotal []atomic.Int64
+
+	// ruleNames mirrors Actions' RuleName in the same order, used to label the OTel counters
+	// below; action labels every one of this DropActions' counters, since all of them describe
+	// the same AllowListActionDrop action type. matchesCounter/droppedCounter are nil when
+	// telemetry construction failed, in which case decide simply skips emitting them.
+	ruleNames      []string
+	action         string
+	matchesCounter metric.Int64Counter
+	droppedCounter metric.Int64Counter
+}
+
+// newRuleCounters builds the in-memory counters plus, using settings.MeterProvider, the
+// processor's awsapm.rule.matches and awsapm.datapoints.dropped self-telemetry instruments.
+// ruleNames is Actions' RuleName for each rule, in order, used as the rule_name attribute; action
+// is the action type (e.g. AllowListActionDrop) shared by all of them, used as the action
+// attribute.
+func newRuleCounters(ruleNames []string, action string, settings component.TelemetrySettings) ruleCounters {
+	n := len(ruleNames)
+	meter := settings.MeterProvider.Meter(meterName)
+
+	matchesCounter, err := meter.Int64Counter(
+		"awsapm.rule.matches",
+		metric.WithDescription("Number of spans/datapoints/log records that matched a custom configuration rule"),
+	)
+	if err != nil {
+		matchesCounter = nil
+	}
+
+	droppedCounter, err := meter.Int64Counter(
+		"awsapm.datapoints.dropped",
+		metric.WithDescription("Number of spans/datapoints/log records dropped by a custom configuration rule"),
+	)
+	if err != nil {
+		droppedCounter = nil
+	}
+
+	return ruleCounters{
+		matchedTotal:    make([]atomic.Int64, n),
+		droppedTotal:    make([]atomic.Int64, n),
+		sampledOutTotal: make([]atomic.Int64, n),
+		ruleNames:       ruleNames,
+		action:          action,
+		matchesCounter:  matchesCounter,
+		droppedCounter:  droppedCounter,
+	}
+}
+
+// MatchedTotal, DroppedTotal, and SampledOutTotal return rule i's running counters.
+func (c *ruleCounters) MatchedTotal(i int) int64    { return c.matchedTotal[i].Load() }
+func (c *ruleCounters) DroppedTotal(i int) int64    { return c.droppedTotal[i].Load() }
+func (c *ruleCounters) SampledOutTotal(i int) int64 { return c.sampledOutTotal[i].Load() }
+
+// decide applies rule i's SamplingPercentage (treated as 100, i.e. always drop, when unset) to
+// sampleKey and records the outcome in this rule's counters.
+func (c *ruleCounters) decide(i int, samplingPercentage float64, sampleKey string) (bool, DropReason) {
+	c.matchedTotal[i].Add(1)
+	c.recordMatch(i)
+
+	if samplingPercentage > 0 && !deterministicSampleDrop(sampleKey, samplingPercentage) {
+		c.sampledOutTotal[i].Add(1)
+		return false, DropReasonSampledOut
+	}
+
+	c.droppedTotal[i].Add(1)
+	c.recordDrop(i)
+	return true, DropReasonDropped
+}
+
+// recordMatch and recordDrop emit this rule's OTel counters, labeled with its rule name, for
+// dashboards built directly on the processor's self-telemetry rather than on the in-process
+// MatchedTotal/DroppedTotal accessors above.
+func (c *ruleCounters) recordMatch(i int) {
+	if c.matchesCounter == nil {
+		return
+	}
+	c.matchesCounter.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("rule_name", c.ruleName(i)),
+		attribute.String("action", c.action),
+	))
+}
+
+func (c *ruleCounters) recordDrop(i int) {
+	if c.droppedCounter == nil {
+		return
+	}
+	c.droppedCounter.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("rule_name", c.ruleName(i)),
+		attribute.String("action", c.action),
+	))
+}
+
+func (c *ruleCounters) ruleName(i int) string {
+	if i < 0 || i >= len(c.ruleNames) {
+		return ""
+	}
+	return c.ruleNames[i]
+}
+
+// deterministicSampleDrop hashes key with FNV-1a and maps it into [0, 100) to decide whether it
+// falls within samplingPercentage's drop bucket. FNV-1a (rather than a keyed/cryptographic hash)
+// is enough here: the input is attacker-uncontrolled telemetry data, and the only property that
+// matters is that the same key always lands in the same bucket, so a trace/entity is never split
+// between being dropped and being kept depending on which span or log record of it happens to be
+// evaluated first.
+func deterministicSampleDrop(key string, samplingPercentage float64) bool {
+	if samplingPercentage >= 100 {
+		return true
+	}
+	if samplingPercentage <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	// h.Sum32() is uniform over [0, 2^32); comparing against a scaled threshold is equivalent to
+	// comparing the hash's fractional position in [0, 1) against samplingPercentage/100.
+	threshold := uint64(samplingPercentage / 100 * (1 << 32))
+	return uint64(h.Sum32()) < threshold
+}
+
+func sampleKeyFromAttributes(attributes pcommon.Map, attributeKey string) string {
+	if attributeKey == "" {
+		attributeKey = defaultSamplingAttributeKey
+	}
+	if val, ok := attributes.Get(attributeKey); ok {
+		return val.AsString()
+	}
+	return ""
+}
+
+// sampleKeyForSpan prefers the span's real trace ID over an attribute lookup when the rule either
+// didn't configure a SamplingAttributeKey, or configured the default trace_id key - spans don't
+// usually carry their trace ID as a regular attribute.
+func sampleKeyForSpan(span ptrace.Span, attributeKey string) string {
+	if attributeKey == "" || attributeKey == defaultSamplingAttributeKey {
+		return span.TraceID().String()
+	}
+	return sampleKeyFromAttributes(span.Attributes(), attributeKey)
+}
+
+// sampleKeyForLogRecord mirrors sampleKeyForSpan for plog.LogRecord, which also carries its trace
+// ID outside of its attribute map.
+func sampleKeyForLogRecord(record plog.LogRecord, attributeKey string) string {
+	if attributeKey == "" || attributeKey == defaultSamplingAttributeKey {
+		return record.TraceID().String()
+	}
+	return sampleKeyFromAttributes(record.Attributes(), attributeKey)
+}
@@ -9,10 +9,12 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -20,10 +22,48 @@ import (
 	semconv "go.opentelemetry.io/collector/semconv/v1.17.0"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// labelServiceName is set by the EndpointSlice controller on every slice it owns.
+	// https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/#ownership
+	labelServiceName = "kubernetes.io/service-name"
+
+	// useListAndWatchEndpointSlicesEnvKey lets clusters without the discovery.k8s.io/v1 API
+	// (or operators who want to keep the old behavior) opt back into the periodic
+	// selector-based ServiceToWorkloadMapper instead of the EndpointSlice watcher.
+	useListAndWatchEndpointSlicesEnvKey = "USE_LIST_WATCH_ENDPOINT_SLICES"
+
+	// Env vars that let operators shrink the resolver's informer scope, mirroring the
+	// DiscoveryNamespacesFilter knobs Istio's kube controller exposes, so a DaemonSet-mode agent
+	// doesn't need cluster-wide "pods"/"services" list/watch RBAC.
+	discoveryNamespaceEnvKey         = "EKS_RESOLVER_NAMESPACE"          // restrict informers to a single namespace; empty means cluster-wide
+	discoveryExcludeNamespacesEnvKey = "EKS_RESOLVER_EXCLUDE_NAMESPACES" // comma-separated namespaces to ignore even within scope
+	discoveryPodLabelSelectorEnvKey  = "EKS_RESOLVER_POD_LABEL_SELECTOR"
+	discoveryNodeNameEnvKey          = "K8S_NODE_NAME" // set by the downward API on DaemonSet-mode agents
+
+	// resolverSnapshotPathEnvKey configures periodic persistence and warm-start of the resolver's
+	// indexes; an empty value (the default) disables snapshotting entirely.
+	resolverSnapshotPathEnvKey = "EKS_RESOLVER_SNAPSHOT_PATH"
+
+	// podResourcesSocketPathEnvKey overrides the kubelet PodResources socket PodResourcesStore
+	// polls; an empty value (the default) falls back to defaultPodResourcesSocket.
+	podResourcesSocketPathEnvKey = "EKS_RESOLVER_POD_RESOURCES_SOCKET"
+
+	// watcherWorkerCount is the number of goroutines draining each watcher's workqueue.
+	// Multiple workers let a burst of pod churn on one workload drain without blocking
+	// updates for unrelated workloads.
+	watcherWorkerCount = 4
+	// maxWatcherRetries bounds how many times a failed event is retried (with backoff)
+	// before it is dropped; our handlers only fail on transient, not permanent, errors.
+	maxWatcherRetries = 5
 )
 
 const (
@@ -31,6 +71,12 @@ const (
 	// https://github.com/kubernetes/apimachinery/blob/master/pkg/util/rand/rand.go#L83
 	kubeAllowedStringAlphaNums = "bcdfghjklmnpqrstvwxz2456789"
 
+	// dnsRefreshInterval controls how often the background DNS refresher re-resolves the
+	// hostnames behind LoadBalancer ingress and ExternalName services. Kubernetes does not
+	// push us changes to these records, so we have to poll; this interval is a compromise
+	// between staleness and the extra DNS lookups it costs.
+	dnsRefreshInterval = 30 * time.Second
+
 	// Deletion delay adjustment:
 	// Previously, EKS resolver would instantly remove the IP to Service mapping when a pod was destroyed.
 	// This posed a problem because:
@@ -65,10 +111,15 @@ type eksResolver struct {
 	podToWorkloadAndNamespace      *sync.Map
 	ipToServiceAndNamespace        *sync.Map
 	serviceAndNamespaceToSelectors *sync.Map
+	serviceAndNamespaceToHeadless  *sync.Map // serviceAndNamespace -> true, populated only for ClusterIP: None services
 	workloadAndNamespaceToLabels   *sync.Map
 	serviceToWorkload              *sync.Map // computed from serviceAndNamespaceToSelectors and workloadAndNamespaceToLabels every 1 min
+	ipToServiceEndpoints           *sync.Map // ip:port (and bare pod ip for headless services) -> serviceAndNamespace, derived from EndpointSlices
+	ipToExcludedNamespace          *sync.Map // ip -> namespace, for pods filtered out of scope by discoveryFilterConfig
 	workloadPodCount               map[string]int
-	safeStopCh                     *safeChannel // trace and metric processors share the same eksResolver and might close the same channel separately
+	podResources                   *PodResourcesStore // container/device id fast path, consulted before any IP-based lookup
+	safeStopCh                     *safeChannel       // trace and metric processors share the same eksResolver and might close the same channel separately
+	telemetry                      *resolverTelemetry
 }
 
 // a safe channel which can be closed multiple times
@@ -162,78 +213,280 @@ func getWorkloadAndNamespace(pod *corev1.Pod) string {
 	return workloadAndNamespace
 }
 
-// Deleter represents a type that can delete a key from a map after a certain delay.
+// Deleter represents a type that can delete a key from a map after a certain delay, provided the
+// map still holds the value the caller expected when it scheduled the deletion.
 type Deleter interface {
-	DeleteWithDelay(m *sync.Map, key interface{})
+	DeleteWithDelay(m *sync.Map, key, expectedValue interface{})
 }
 
-// TimedDeleter deletes a key after a specified delay.
+// TimedDeleter deletes a key after a specified delay, but only if the entry hasn't since been
+// overwritten. This guards against, e.g., CNI IPAM recycling a Pod IP to a brand-new pod within
+// deletionDelay: without the check, the delayed delete scheduled for the old pod would remove the
+// new pod's freshly-written mapping. Mirrors the conditional-release pattern OVN-Kubernetes uses
+// for its own delayed IP release.
 type TimedDeleter struct {
 	Delay time.Duration
+	// deleteSkippedDueToReuse counts how many scheduled deletions were skipped because the key
+	// had already been reclaimed by something else, so operators can observe IP churn.
+	deleteSkippedDueToReuse atomic.Int64
 }
 
-func (td *TimedDeleter) DeleteWithDelay(m *sync.Map, key interface{}) {
+func (td *TimedDeleter) DeleteWithDelay(m *sync.Map, key, expectedValue interface{}) {
 	go func() {
 		time.Sleep(td.Delay)
+		if current, ok := m.Load(key); ok && expectedValue != nil && current != expectedValue {
+			td.deleteSkippedDueToReuse.Add(1)
+			return
+		}
 		m.Delete(key)
 	}()
 }
 
-func onAddOrUpdateService(obj interface{}, ipToServiceAndNamespace, serviceAndNamespaceToSelectors *sync.Map) {
+// DeleteSkippedDueToReuse returns the running count of deletions that were skipped because the
+// key had already been reclaimed by a new owner before deletionDelay elapsed.
+func (td *TimedDeleter) DeleteSkippedDueToReuse() int64 {
+	return td.deleteSkippedDueToReuse.Load()
+}
+
+// onAddOrUpdateService indexes ClusterIP services directly, and for the three cases that used
+// to be silently dropped (headless services, LoadBalancer/Ingress-fronted services, and
+// ExternalName services) registers enough information for ipToServiceAndNamespace to eventually
+// carry every address a client could plausibly dial.
+//
+// [ec2-user@ip-172-31-11-104 one-step]$ k get svc -A
+// NAMESPACE           NAME                          TYPE           CLUSTER-IP       EXTERNAL-IP                                                              PORT(S)                                     AGE
+// default             pet-clinic-frontend           ClusterIP      10.100.216.182   <none>                                                                   8080/TCP                                    108m
+// default             vets-service                  ClusterIP      10.100.62.167    <none>                                                                   8083/TCP                                    108m
+// default             visits-service                ClusterIP      10.100.96.5      <none>                                                                   8082/TCP                                    108m
+// ingress-nginx       default-http-backend          ClusterIP      10.100.11.231    <none>                                                                   80/TCP                                      108m
+// ingress-nginx       ingress-nginx                 LoadBalancer   10.100.154.5     aex7997ece08c435dbd2b912fd5aa5bd-5372117830.xxxxx.elb.amazonaws.com      80:32080/TCP,443:32081/TCP,9113:30410/TCP   108m
+// kube-system         kube-dns                      ClusterIP      10.100.0.10      <none>
+func onAddOrUpdateService(obj interface{}, ipToServiceAndNamespace, serviceAndNamespaceToSelectors, serviceAndNamespaceToHeadless, dnsRefreshTargets *sync.Map) {
 	service := obj.(*corev1.Service)
-	// service can also have an external IP (or ingress IP) that could be accessed
-	// this field can be either an IP address (in some edge case) or a hostname (see "EXTERNAL-IP" column in "k get svc" output)
-	// [ec2-user@ip-172-31-11-104 one-step]$ k get svc -A
-	// NAMESPACE           NAME                          TYPE           CLUSTER-IP       EXTERNAL-IP                                                              PORT(S)                                     AGE
-	// default             pet-clinic-frontend           ClusterIP      10.100.216.182   <none>                                                                   8080/TCP                                    108m
-	// default             vets-service                  ClusterIP      10.100.62.167    <none>                                                                   8083/TCP                                    108m
-	// default             visits-service                ClusterIP      10.100.96.5      <none>                                                                   8082/TCP                                    108m
-	// ingress-nginx       default-http-backend          ClusterIP      10.100.11.231    <none>                                                                   80/TCP                                      108m
-	// ingress-nginx       ingress-nginx                 LoadBalancer   10.100.154.5     aex7997ece08c435dbd2b912fd5aa5bd-5372117830.xxxxx.elb.amazonaws.com      80:32080/TCP,443:32081/TCP,9113:30410/TCP   108m
-	// kube-system         kube-dns                      ClusterIP      10.100.0.10      <none>
-	//
-	// we ignore such case for now and may need to consider it in the future
-	if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != "None" {
-		ipToServiceAndNamespace.Store(service.Spec.ClusterIP, getServiceAndNamespace(service))
+	serviceAndNamespace := getServiceAndNamespace(service)
+
+	switch {
+	case service.Spec.ClusterIP == "None":
+		// headless service: no ClusterIP to index, GetWorkloadAndNamespaceByIP instead falls
+		// through to the pod IPs backing it, surfaced via the EndpointSlice watcher. Record
+		// that this service is headless so EndpointSlice processing knows to also populate
+		// ipToServiceAndNamespace (rather than only ipToServiceEndpoints) for its addresses.
+		serviceAndNamespaceToHeadless.Store(serviceAndNamespace, true)
+	case service.Spec.ClusterIP != "":
+		ipToServiceAndNamespace.Store(service.Spec.ClusterIP, serviceAndNamespace)
 	}
+
+	for _, externalIP := range service.Spec.ExternalIPs {
+		ipToServiceAndNamespace.Store(externalIP, serviceAndNamespace)
+	}
+
+	if service.Spec.Type == corev1.ServiceTypeExternalName && service.Spec.ExternalName != "" {
+		dnsRefreshTargets.Store(service.Spec.ExternalName, serviceAndNamespace)
+	}
+
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ipToServiceAndNamespace.Store(ingress.IP, serviceAndNamespace)
+		}
+		if ingress.Hostname != "" {
+			dnsRefreshTargets.Store(ingress.Hostname, serviceAndNamespace)
+		}
+	}
+
 	labelSet := mapset.NewSet[string]()
 	for key, value := range service.Spec.Selector {
 		labelSet.Add(key + "=" + value)
 	}
 	if labelSet.Cardinality() > 0 {
-		serviceAndNamespaceToSelectors.Store(getServiceAndNamespace(service), labelSet)
+		serviceAndNamespaceToSelectors.Store(serviceAndNamespace, labelSet)
 	}
 }
 
-func onDeleteService(obj interface{}, ipToServiceAndNamespace, serviceAndNamespaceToSelectors *sync.Map, deleter Deleter) {
+func onDeleteService(obj interface{}, ipToServiceAndNamespace, serviceAndNamespaceToSelectors, serviceAndNamespaceToHeadless, dnsRefreshTargets *sync.Map, deleter Deleter) {
 	service := obj.(*corev1.Service)
+	serviceAndNamespace := getServiceAndNamespace(service)
+
 	if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != "None" {
-		deleter.DeleteWithDelay(ipToServiceAndNamespace, service.Spec.ClusterIP)
+		deleter.DeleteWithDelay(ipToServiceAndNamespace, service.Spec.ClusterIP, serviceAndNamespace)
+	}
+	for _, externalIP := range service.Spec.ExternalIPs {
+		deleter.DeleteWithDelay(ipToServiceAndNamespace, externalIP, serviceAndNamespace)
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			deleter.DeleteWithDelay(ipToServiceAndNamespace, ingress.IP, serviceAndNamespace)
+		}
+		if ingress.Hostname != "" {
+			dnsRefreshTargets.Delete(ingress.Hostname)
+		}
+	}
+	if service.Spec.Type == corev1.ServiceTypeExternalName {
+		dnsRefreshTargets.Delete(service.Spec.ExternalName)
+	}
+	serviceAndNamespaceToHeadless.Delete(serviceAndNamespace)
+	deleter.DeleteWithDelay(serviceAndNamespaceToSelectors, serviceAndNamespace, nil)
+}
+
+// dnsRefresher periodically re-resolves every hostname registered in dnsRefreshTargets (the
+// hostname behind a LoadBalancer Ingress, or an ExternalName CNAME target) and stores every
+// resulting A/AAAA record into ipToServiceAndNamespace, so a trace carrying the resolved peer IP
+// of an out-of-cluster or DNS-fronted dependency still maps back to the owning Service. It also
+// remembers, per target, the addresses it resolved to last time, so an address that drops out of
+// a target's DNS answer - or a target removed from dnsRefreshTargets entirely - gets pruned from
+// ipToServiceAndNamespace instead of being kept there forever.
+type dnsRefresher struct {
+	ipToServiceAndNamespace *sync.Map
+	dnsRefreshTargets       *sync.Map
+	resolvedIPs             *sync.Map // hostname -> []string, the addresses resolved on the previous refreshOnce
+	logger                  *zap.Logger
+	deleter                 Deleter
+	resolve                 func(hostname string) ([]net.IP, error)
+}
+
+func newDNSRefresher(ipToServiceAndNamespace, dnsRefreshTargets *sync.Map, logger *zap.Logger, deleter Deleter) *dnsRefresher {
+	return &dnsRefresher{
+		ipToServiceAndNamespace: ipToServiceAndNamespace,
+		dnsRefreshTargets:       dnsRefreshTargets,
+		resolvedIPs:             &sync.Map{},
+		logger:                  logger,
+		deleter:                 deleter,
+		resolve:                 net.LookupIP,
+	}
+}
+
+func (r *dnsRefresher) refreshOnce() {
+	active := make(map[string]struct{})
+
+	r.dnsRefreshTargets.Range(func(key, value interface{}) bool {
+		hostname := key.(string)
+		serviceAndNamespace := value.(string)
+		active[hostname] = struct{}{}
+
+		ips, err := r.resolve(hostname)
+		if err != nil {
+			r.logger.Debug("failed to resolve dns refresh target", zap.String("hostname", hostname), zap.Error(err))
+			return true
+		}
+
+		newIPs := make(map[string]struct{}, len(ips))
+		for _, ip := range ips {
+			ipStr := ip.String()
+			newIPs[ipStr] = struct{}{}
+			r.ipToServiceAndNamespace.Store(ipStr, serviceAndNamespace)
+		}
+		r.pruneStaleIPs(hostname, newIPs)
+		return true
+	})
+
+	// a target removed from dnsRefreshTargets (its owning Service was deleted, or its
+	// ExternalName/ingress hostname changed) never resolves again, so it never reaches the
+	// pruning above; treat it as though it now resolves to nothing.
+	r.resolvedIPs.Range(func(key, _ interface{}) bool {
+		hostname := key.(string)
+		if _, ok := active[hostname]; !ok {
+			r.pruneStaleIPs(hostname, nil)
+		}
+		return true
+	})
+}
+
+// pruneStaleIPs records hostname's newly resolved address set, deleting (via the delayed-delete
+// path every other eventual removal in this package uses) any address hostname resolved to last
+// time but that isn't part of newIPs.
+func (r *dnsRefresher) pruneStaleIPs(hostname string, newIPs map[string]struct{}) {
+	if oldVal, ok := r.resolvedIPs.Load(hostname); ok {
+		for _, ip := range oldVal.([]string) {
+			if _, stillPresent := newIPs[ip]; !stillPresent {
+				r.deleter.DeleteWithDelay(r.ipToServiceAndNamespace, ip, nil)
+			}
+		}
+	}
+
+	if len(newIPs) == 0 {
+		r.resolvedIPs.Delete(hostname)
+		return
 	}
-	deleter.DeleteWithDelay(serviceAndNamespaceToSelectors, getServiceAndNamespace(service))
+	ips := make([]string, 0, len(newIPs))
+	for ip := range newIPs {
+		ips = append(ips, ip)
+	}
+	r.resolvedIPs.Store(hostname, ips)
+}
+
+func (r *dnsRefresher) Start(stopCh chan struct{}) {
+	go func() {
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(dnsRefreshInterval):
+				r.refreshOnce()
+			}
+		}
+	}()
 }
 
 func removeHostNetworkRecords(pod *corev1.Pod, ipToPod *sync.Map, deleter Deleter) {
+	hostIP := normalizeIP(pod.Status.HostIP)
 	for _, port := range getHostNetworkPorts(pod) {
-		deleter.DeleteWithDelay(ipToPod, pod.Status.HostIP+":"+port)
+		deleter.DeleteWithDelay(ipToPod, hostIP+":"+port, pod.Name)
+	}
+}
+
+// normalizeIP canonicalizes an address via net.ParseIP/String so the same host is always keyed
+// identically regardless of which textual form (IPv4-mapped IPv6, non-canonical IPv6
+// compression, etc.) a given client or the apiserver happened to report it in. Values that
+// aren't valid IPs (shouldn't happen for the fields we call this on) are passed through as-is.
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+// podIPs returns every normalized address a pod is reachable at: Status.PodIP plus every entry
+// of Status.PodIPs (the latter being how dual-stack clusters report both the IPv4 and IPv6
+// address of the same pod), deduplicated.
+func podIPs(pod *corev1.Pod) []string {
+	seen := make(map[string]struct{}, len(pod.Status.PodIPs)+1)
+	var ips []string
+	add := func(ip string) {
+		if ip == "" {
+			return
+		}
+		ip = normalizeIP(ip)
+		if _, ok := seen[ip]; ok {
+			return
+		}
+		seen[ip] = struct{}{}
+		ips = append(ips, ip)
+	}
+
+	add(pod.Status.PodIP)
+	for _, podIP := range pod.Status.PodIPs {
+		add(podIP.IP)
 	}
+	return ips
 }
 
 func updateHostNetworkRecords(newPod *corev1.Pod, oldPod *corev1.Pod, ipToPod *sync.Map, deleter Deleter) {
 	newHostIPPorts := make(map[string]bool)
 	oldHostIPPorts := make(map[string]bool)
 
+	newHostIP := normalizeIP(newPod.Status.HostIP)
+	oldHostIP := normalizeIP(oldPod.Status.HostIP)
+
 	for _, port := range getHostNetworkPorts(newPod) {
-		newHostIPPorts[newPod.Status.HostIP+":"+port] = true
+		newHostIPPorts[newHostIP+":"+port] = true
 	}
 
 	for _, port := range getHostNetworkPorts(oldPod) {
-		oldHostIPPorts[oldPod.Status.HostIP+":"+port] = true
+		oldHostIPPorts[oldHostIP+":"+port] = true
 	}
 
 	for oldHostIPPort := range oldHostIPPorts {
 		if _, exist := newHostIPPorts[oldHostIPPort]; !exist {
-			deleter.DeleteWithDelay(ipToPod, oldHostIPPort)
+			deleter.DeleteWithDelay(ipToPod, oldHostIPPort, oldPod.Name)
 		}
 	}
 
@@ -246,11 +499,14 @@ func updateHostNetworkRecords(newPod *corev1.Pod, oldPod *corev1.Pod, ipToPod *s
 
 func handlePodAdd(pod *corev1.Pod, ipToPod *sync.Map) {
 	if pod.Spec.HostNetwork {
+		hostIP := normalizeIP(pod.Status.HostIP)
 		for _, port := range getHostNetworkPorts(pod) {
-			ipToPod.Store(pod.Status.HostIP+":"+port, pod.Name)
+			ipToPod.Store(hostIP+":"+port, pod.Name)
+		}
+	} else {
+		for _, ip := range podIPs(pod) {
+			ipToPod.Store(ip, pod.Name)
 		}
-	} else if pod.Status.PodIP != "" {
-		ipToPod.Store(pod.Status.PodIP, pod.Name)
 	}
 }
 
@@ -261,33 +517,44 @@ func handlePodUpdate(newPod *corev1.Pod, oldPod *corev1.Pod, ipToPod *sync.Map,
 		updateHostNetworkRecords(newPod, oldPod, ipToPod, deleter)
 	} else if oldPod.Spec.HostNetwork && !newPod.Spec.HostNetwork {
 		// Case 2: The oldPod was using the host network, but the newPod is not
-		// Here we remove the old host network records and add new PodIP record if it is not empty
+		// Here we remove the old host network records and add new PodIP records if any
 		removeHostNetworkRecords(oldPod, ipToPod, deleter)
-		if newPod.Status.PodIP != "" {
-			ipToPod.Store(newPod.Status.PodIP, newPod.Name)
+		for _, ip := range podIPs(newPod) {
+			ipToPod.Store(ip, newPod.Name)
 		}
 	} else if !oldPod.Spec.HostNetwork && newPod.Spec.HostNetwork {
 		// Case 3: The oldPod was not using the host network, but the newPod is
-		// Here we remove the old PodIP record and add new host network records
-		if oldPod.Status.PodIP != "" {
-			deleter.DeleteWithDelay(ipToPod, oldPod.Status.PodIP)
+		// Here we remove the old PodIP records and add new host network records
+		for _, ip := range podIPs(oldPod) {
+			deleter.DeleteWithDelay(ipToPod, ip, oldPod.Name)
 		}
+		hostIP := normalizeIP(newPod.Status.HostIP)
 		for _, port := range getHostNetworkPorts(newPod) {
-			ipToPod.Store(newPod.Status.HostIP+":"+port, newPod.Name)
+			ipToPod.Store(hostIP+":"+port, newPod.Name)
+		}
+	} else if !oldPod.Spec.HostNetwork && !newPod.Spec.HostNetwork {
+		// Case 4: Both oldPod and newPod are not using the host network; reconcile the
+		// (possibly dual-stack) address sets instead of comparing a single PodIP, so e.g. a
+		// pod gaining/losing its IPv6 address on an otherwise-unchanged IPv4 address is
+		// handled correctly.
+		oldIPs := podIPs(oldPod)
+		newIPSet := make(map[string]struct{}, len(newPod.Status.PodIPs)+1)
+		for _, ip := range podIPs(newPod) {
+			newIPSet[ip] = struct{}{}
 		}
-	} else if !oldPod.Spec.HostNetwork && !newPod.Spec.HostNetwork && oldPod.Status.PodIP != newPod.Status.PodIP {
-		// Case 4: Both oldPod and newPod are not using the host network, but the Pod IPs are different
-		// Here we replace the old PodIP record with the new one
-		if oldPod.Status.PodIP != "" {
-			deleter.DeleteWithDelay(ipToPod, oldPod.Status.PodIP)
+
+		for _, ip := range oldIPs {
+			if _, stillPresent := newIPSet[ip]; !stillPresent {
+				deleter.DeleteWithDelay(ipToPod, ip, oldPod.Name)
+			}
 		}
-		if newPod.Status.PodIP != "" {
-			ipToPod.Store(newPod.Status.PodIP, newPod.Name)
+		for ip := range newIPSet {
+			ipToPod.Store(ip, newPod.Name)
 		}
 	}
 }
 
-func onAddOrUpdatePod(newObj, oldObj interface{}, ipToPod, podToWorkloadAndNamespace, workloadAndNamespaceToLabels *sync.Map, workloadPodCount map[string]int, isAdd bool, logger *zap.Logger, deleter Deleter) {
+func onAddOrUpdatePod(newObj, oldObj interface{}, ipToPod, podToWorkloadAndNamespace, workloadAndNamespaceToLabels *sync.Map, workloadPodCount map[string]int, workloadPodCountMu *sync.Mutex, isAdd bool, logger *zap.Logger, deleter Deleter) {
 	pod := newObj.(*corev1.Pod)
 
 	if isAdd {
@@ -309,31 +576,43 @@ func onAddOrUpdatePod(newObj, oldObj interface{}, ipToPod, podToWorkloadAndNames
 			workloadAndNamespaceToLabels.Store(workloadAndNamespace, podLabels)
 		}
 		if isAdd {
+			workloadPodCountMu.Lock()
 			workloadPodCount[workloadAndNamespace]++
-			logger.Debug("Added pod", zap.String("pod", pod.Name), zap.String("workload", workloadAndNamespace), zap.Int("count", workloadPodCount[workloadAndNamespace]))
+			count := workloadPodCount[workloadAndNamespace]
+			workloadPodCountMu.Unlock()
+			logger.Debug("Added pod", zap.String("pod", pod.Name), zap.String("workload", workloadAndNamespace), zap.Int("count", count))
 		}
 	}
 }
 
-func onDeletePod(obj interface{}, ipToPod, podToWorkloadAndNamespace, workloadAndNamespaceToLabels *sync.Map, workloadPodCount map[string]int, logger *zap.Logger, deleter Deleter) {
+func onDeletePod(obj interface{}, ipToPod, podToWorkloadAndNamespace, workloadAndNamespaceToLabels, ipToExcludedNamespace *sync.Map, workloadPodCount map[string]int, workloadPodCountMu *sync.Mutex, logger *zap.Logger, deleter Deleter) {
 	pod := obj.(*corev1.Pod)
-	if pod.Status.PodIP != "" {
-		deleter.DeleteWithDelay(ipToPod, pod.Status.PodIP)
+	if len(podIPs(pod)) > 0 {
+		for _, ip := range podIPs(pod) {
+			deleter.DeleteWithDelay(ipToPod, ip, pod.Name)
+			if ipToExcludedNamespace != nil {
+				deleter.DeleteWithDelay(ipToExcludedNamespace, ip, nil)
+			}
+		}
 	} else if pod.Status.HostIP != "" {
+		hostIP := normalizeIP(pod.Status.HostIP)
 		for _, port := range getHostNetworkPorts(pod) {
-			deleter.DeleteWithDelay(ipToPod, pod.Status.HostIP+":"+port)
+			deleter.DeleteWithDelay(ipToPod, hostIP+":"+port, pod.Name)
 		}
 	}
 
 	if workloadKey, ok := podToWorkloadAndNamespace.Load(pod.Name); ok {
 		workloadAndNamespace := workloadKey.(string)
+		workloadPodCountMu.Lock()
 		workloadPodCount[workloadAndNamespace]--
-		logger.Debug("workload pod count", zap.String("workload", workloadAndNamespace), zap.Int("podCount", workloadPodCount[workloadAndNamespace]))
-		if workloadPodCount[workloadAndNamespace] == 0 {
-			deleter.DeleteWithDelay(workloadAndNamespaceToLabels, workloadAndNamespace)
+		count := workloadPodCount[workloadAndNamespace]
+		workloadPodCountMu.Unlock()
+		logger.Debug("workload pod count", zap.String("workload", workloadAndNamespace), zap.Int("podCount", count))
+		if count == 0 {
+			deleter.DeleteWithDelay(workloadAndNamespaceToLabels, workloadAndNamespace, nil)
 		}
 	}
-	deleter.DeleteWithDelay(podToWorkloadAndNamespace, pod.Name)
+	deleter.DeleteWithDelay(podToWorkloadAndNamespace, pod.Name, nil)
 }
 
 type PodWatcher struct {
@@ -341,41 +620,207 @@ type PodWatcher struct {
 	podToWorkloadAndNamespace    *sync.Map
 	workloadAndNamespaceToLabels *sync.Map
 	workloadPodCount             map[string]int
-	logger                       *zap.Logger
-	informer                     cache.SharedIndexInformer
-	deleter                      Deleter
+	workloadPodCountMu           sync.Mutex // workloadPodCount is a plain map touched by every worker goroutine
+	ipToExcludedNamespace        *sync.Map  // ip -> namespace, for pods filtered out by discoveryFilterConfig.excludeNamespaces
+	excludeNamespaces            map[string]struct{}
+	// lastSeenPod remembers, per namespace/name key, the pod object this watcher last
+	// successfully processed, so a worker that re-fetches the current object from the informer
+	// store still has something to diff an update against.
+	lastSeenPod *sync.Map
+	// tombstones holds the last known object for a key whose DeleteFunc already fired - by the
+	// time a worker gets around to the key, the informer's store no longer has it.
+	tombstones *sync.Map
+	logger     *zap.Logger
+	informer   cache.SharedIndexInformer
+	deleter    Deleter
+	queue      workqueue.RateLimitingInterface
 }
 
 func NewPodWatcher(logger *zap.Logger, informer cache.SharedIndexInformer, deleter Deleter) *PodWatcher {
+	return NewFilteredPodWatcher(logger, informer, deleter, nil)
+}
+
+// NewFilteredPodWatcher is NewPodWatcher plus a set of namespaces to ignore even though the
+// informer itself is scoped more broadly (e.g. cluster-wide with only the node field selector
+// applied). Pods in an excluded namespace are recorded in ipToExcludedNamespace so
+// GetWorkloadAndNamespaceByIP can report ErrIPOutOfResolverScope instead of a generic miss.
+func NewFilteredPodWatcher(logger *zap.Logger, informer cache.SharedIndexInformer, deleter Deleter, excludeNamespaces map[string]struct{}) *PodWatcher {
 	return &PodWatcher{
 		ipToPod:                      &sync.Map{},
 		podToWorkloadAndNamespace:    &sync.Map{},
 		workloadAndNamespaceToLabels: &sync.Map{},
 		workloadPodCount:             make(map[string]int),
+		ipToExcludedNamespace:        &sync.Map{},
+		excludeNamespaces:            excludeNamespaces,
+		lastSeenPod:                  &sync.Map{},
+		tombstones:                   &sync.Map{},
 		logger:                       logger,
 		informer:                     informer,
 		deleter:                      deleter,
+		queue:                        workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 	}
 }
 
+func (p *PodWatcher) isExcluded(namespace string) bool {
+	if len(p.excludeNamespaces) == 0 {
+		return false
+	}
+	_, excluded := p.excludeNamespaces[namespace]
+	return excluded
+}
+
+// Run registers lightweight informer handlers that only enqueue the event's namespace/name key,
+// then starts the informer and a pool of workers that drain the queue. Enqueuing a comparable key
+// (rather than a freshly allocated work item) is what lets the workqueue actually deduplicate a
+// burst of rapid updates to the same pod down to a single requeue; a worker re-fetches the current
+// object from the informer's own store, so it always sees the latest version rather than
+// whichever one happened to be enqueued first. Deletes are carried via the tombstones side-map
+// since the object is already gone from the store by the time a worker gets to the key, and a
+// transient processing failure gets retried with backoff via AddRateLimited instead of silently
+// dropping the event.
 func (p *PodWatcher) Run(stopCh chan struct{}) {
 	p.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			p.logger.Debug("list and watch for pods: ADD")
-			onAddOrUpdatePod(obj, nil, p.ipToPod, p.podToWorkloadAndNamespace, p.workloadAndNamespaceToLabels, p.workloadPodCount, true, p.logger, p.deleter)
+			p.enqueue(obj)
 		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			p.logger.Debug("list and watch for pods: UPDATE")
-			onAddOrUpdatePod(newObj, oldObj, p.ipToPod, p.podToWorkloadAndNamespace, p.workloadAndNamespaceToLabels, p.workloadPodCount, false, p.logger, p.deleter)
+		UpdateFunc: func(_, newObj interface{}) {
+			p.enqueue(newObj)
 		},
 		DeleteFunc: func(obj interface{}) {
-			p.logger.Debug("list and watch for pods: DELETE")
-			onDeletePod(obj, p.ipToPod, p.podToWorkloadAndNamespace, p.workloadAndNamespaceToLabels, p.workloadPodCount, p.logger, p.deleter)
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					p.logger.Error("pod delete event had unexpected object type", zap.Any("obj", obj))
+					return
+				}
+				pod, ok = tombstone.Obj.(*corev1.Pod)
+				if !ok {
+					p.logger.Error("pod delete event tombstone had unexpected object type", zap.Any("obj", tombstone.Obj))
+					return
+				}
+			}
+			key, err := cache.MetaNamespaceKeyFunc(pod)
+			if err != nil {
+				p.logger.Error("failed to compute key for deleted pod", zap.String("pod", pod.Name), zap.Error(err))
+				return
+			}
+			p.tombstones.Store(key, pod)
+			p.queue.Add(key)
 		},
 	})
 
 	go p.informer.Run(stopCh)
 
+	for i := 0; i < watcherWorkerCount; i++ {
+		go p.runWorker()
+	}
+
+	go func() {
+		<-stopCh
+		p.queue.ShutDown()
+	}()
+}
+
+func (p *PodWatcher) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		p.logger.Error("failed to compute key for pod", zap.Error(err))
+		return
+	}
+	p.queue.Add(key)
+}
+
+func (p *PodWatcher) runWorker() {
+	for p.processNextItem() {
+	}
+}
+
+func (p *PodWatcher) processNextItem() bool {
+	item, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(item)
+
+	key := item.(string)
+	if err := p.process(key); err != nil {
+		if p.queue.NumRequeues(item) < maxWatcherRetries {
+			p.logger.Debug("requeuing pod event after error", zap.String("key", key), zap.Error(err))
+			p.queue.AddRateLimited(item)
+			return true
+		}
+		p.logger.Error("dropping pod event after too many retries", zap.String("key", key), zap.Error(err))
+	}
+	p.queue.Forget(item)
+	return true
+}
+
+// process looks the key up in the informer's own store (rather than trusting whatever object
+// shape the event handler saw) so it always reflects the latest version, diffs it against the
+// last version this watcher itself processed, and falls through to the tombstone recorded by
+// DeleteFunc when the key is no longer in the store at all.
+func (p *PodWatcher) process(key string) error {
+	obj, exists, err := p.informer.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		tomb, ok := p.tombstones.LoadAndDelete(key)
+		if !ok {
+			// nothing to reconstruct a delete from (e.g. the watcher never saw an add for this
+			// key); nothing more to clean up.
+			return nil
+		}
+		pod := tomb.(*corev1.Pod)
+		p.logger.Debug("list and watch for pods: DELETE")
+		onDeletePod(pod, p.ipToPod, p.podToWorkloadAndNamespace, p.workloadAndNamespaceToLabels, p.ipToExcludedNamespace, p.workloadPodCount, &p.workloadPodCountMu, p.logger, p.deleter)
+		p.lastSeenPod.Delete(key)
+		return nil
+	}
+
+	pod := obj.(*corev1.Pod)
+	if p.isExcluded(pod.Namespace) {
+		newIPSet := make(map[string]struct{}, len(pod.Status.PodIPs)+1)
+		for _, ip := range podIPs(pod) {
+			newIPSet[ip] = struct{}{}
+			p.ipToExcludedNamespace.Store(ip, pod.Namespace)
+		}
+		// An excluded pod can still get a new IP (e.g. restart), so diff against whatever this
+		// watcher last saw for it and prune anything no longer current - otherwise ipToExcludedNamespace
+		// leaks one stale entry per IP churn, same as ipToPod does for in-scope pods.
+		if v, ok := p.lastSeenPod.Load(key); ok {
+			for _, ip := range podIPs(v.(*corev1.Pod)) {
+				if _, stillPresent := newIPSet[ip]; !stillPresent {
+					p.deleter.DeleteWithDelay(p.ipToExcludedNamespace, ip, nil)
+				}
+			}
+		}
+		p.lastSeenPod.Store(key, pod)
+		p.tombstones.Delete(key)
+		return nil
+	}
+
+	var oldPod *corev1.Pod
+	isAdd := true
+	if v, ok := p.lastSeenPod.Load(key); ok {
+		oldPod = v.(*corev1.Pod)
+		isAdd = false
+	}
+
+	if isAdd {
+		p.logger.Debug("list and watch for pods: ADD")
+	} else {
+		p.logger.Debug("list and watch for pods: UPDATE")
+	}
+	onAddOrUpdatePod(pod, oldPod, p.ipToPod, p.podToWorkloadAndNamespace, p.workloadAndNamespaceToLabels, p.workloadPodCount, &p.workloadPodCountMu, isAdd, p.logger, p.deleter)
+	p.lastSeenPod.Store(key, pod)
+	// the pod may have been deleted and recreated under the same key between the delete event
+	// firing and this add/update being processed; don't let a stale tombstone cause a spurious
+	// delete the next time this key's queue entry (if any is still pending) is processed.
+	p.tombstones.Delete(key)
+	return nil
 }
 
 func (p *PodWatcher) WaitForCacheSync(stopCh chan struct{}) {
@@ -389,37 +834,141 @@ func (p *PodWatcher) WaitForCacheSync(stopCh chan struct{}) {
 type ServiceWatcher struct {
 	ipToServiceAndNamespace        *sync.Map
 	serviceAndNamespaceToSelectors *sync.Map
-	logger                         *zap.Logger
-	informer                       cache.SharedIndexInformer
-	deleter                        Deleter
+	serviceAndNamespaceToHeadless  *sync.Map
+	dnsRefreshTargets              *sync.Map
+	// tombstones holds the last known object for a key whose DeleteFunc already fired - by the
+	// time a worker gets to the key, the informer's store no longer has it.
+	tombstones *sync.Map
+	logger     *zap.Logger
+	informer   cache.SharedIndexInformer
+	deleter    Deleter
+	queue      workqueue.RateLimitingInterface
 }
 
 func NewServiceWatcher(logger *zap.Logger, informer cache.SharedIndexInformer, deleter Deleter) *ServiceWatcher {
 	return &ServiceWatcher{
 		ipToServiceAndNamespace:        &sync.Map{},
 		serviceAndNamespaceToSelectors: &sync.Map{},
+		serviceAndNamespaceToHeadless:  &sync.Map{},
+		dnsRefreshTargets:              &sync.Map{},
+		tombstones:                     &sync.Map{},
 		logger:                         logger,
 		informer:                       informer,
 		deleter:                        deleter,
+		queue:                          workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 	}
 }
 
+// Run mirrors PodWatcher.Run: informer handlers only enqueue the service's namespace/name key, so
+// rapid updates to the same service coalesce into a single requeue, and a worker re-fetches the
+// current object from the informer's own store rather than trusting whichever version happened to
+// be enqueued first. Deletes are carried via the tombstones side-map since the object is already
+// gone from the store by the time a worker gets to the key.
 func (s *ServiceWatcher) Run(stopCh chan struct{}) {
 	s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			s.logger.Debug("list and watch for services: ADD")
-			onAddOrUpdateService(obj, s.ipToServiceAndNamespace, s.serviceAndNamespaceToSelectors)
+			s.enqueue(obj)
 		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			s.logger.Debug("list and watch for services: UPDATE")
-			onAddOrUpdateService(newObj, s.ipToServiceAndNamespace, s.serviceAndNamespaceToSelectors)
+		UpdateFunc: func(_, newObj interface{}) {
+			s.enqueue(newObj)
 		},
 		DeleteFunc: func(obj interface{}) {
-			s.logger.Debug("list and watch for services: DELETE")
-			onDeleteService(obj, s.ipToServiceAndNamespace, s.serviceAndNamespaceToSelectors, s.deleter)
+			service, ok := obj.(*corev1.Service)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					s.logger.Error("service delete event had unexpected object type", zap.Any("obj", obj))
+					return
+				}
+				service, ok = tombstone.Obj.(*corev1.Service)
+				if !ok {
+					s.logger.Error("service delete event tombstone had unexpected object type", zap.Any("obj", tombstone.Obj))
+					return
+				}
+			}
+			key, err := cache.MetaNamespaceKeyFunc(service)
+			if err != nil {
+				s.logger.Error("failed to compute key for deleted service", zap.String("service", service.Name), zap.Error(err))
+				return
+			}
+			s.tombstones.Store(key, service)
+			s.queue.Add(key)
 		},
 	})
 	go s.informer.Run(stopCh)
+
+	for i := 0; i < watcherWorkerCount; i++ {
+		go s.runWorker()
+	}
+
+	go func() {
+		<-stopCh
+		s.queue.ShutDown()
+	}()
+
+	newDNSRefresher(s.ipToServiceAndNamespace, s.dnsRefreshTargets, s.logger, s.deleter).Start(stopCh)
+}
+
+func (s *ServiceWatcher) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		s.logger.Error("failed to compute key for service", zap.Error(err))
+		return
+	}
+	s.queue.Add(key)
+}
+
+func (s *ServiceWatcher) runWorker() {
+	for s.processNextItem() {
+	}
+}
+
+func (s *ServiceWatcher) processNextItem() bool {
+	item, shutdown := s.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer s.queue.Done(item)
+
+	key := item.(string)
+	if err := s.process(key); err != nil {
+		if s.queue.NumRequeues(item) < maxWatcherRetries {
+			s.logger.Debug("requeuing service event after error", zap.String("key", key), zap.Error(err))
+			s.queue.AddRateLimited(item)
+			return true
+		}
+		s.logger.Error("dropping service event after too many retries", zap.String("key", key), zap.Error(err))
+	}
+	s.queue.Forget(item)
+	return true
+}
+
+// process mirrors PodWatcher.process: look the key up in the informer's own store, falling back
+// to the tombstone recorded by DeleteFunc once the key is gone from the store entirely.
+func (s *ServiceWatcher) process(key string) error {
+	obj, exists, err := s.informer.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		tomb, ok := s.tombstones.LoadAndDelete(key)
+		if !ok {
+			return nil
+		}
+		s.logger.Debug("list and watch for services: DELETE")
+		onDeleteService(tomb.(*corev1.Service), s.ipToServiceAndNamespace, s.serviceAndNamespaceToSelectors, s.serviceAndNamespaceToHeadless, s.dnsRefreshTargets, s.deleter)
+		return nil
+	}
+
+	s.logger.Debug("list and watch for services: ADD/UPDATE")
+	onAddOrUpdateService(obj.(*corev1.Service), s.ipToServiceAndNamespace, s.serviceAndNamespaceToSelectors, s.serviceAndNamespaceToHeadless, s.dnsRefreshTargets)
+	// the service may have been deleted and recreated under the same key between the delete
+	// event firing and this add/update being processed; don't let a stale tombstone cause a
+	// spurious delete the next time this key's queue entry (if any is still pending) is
+	// processed.
+	s.tombstones.Delete(key)
+	return nil
 }
 
 func (s *ServiceWatcher) WaitForCacheSync(stopCh chan struct{}) {
@@ -430,6 +979,119 @@ func (s *ServiceWatcher) WaitForCacheSync(stopCh chan struct{}) {
 	s.logger.Info("ServiceWatcher: Cache synced")
 }
 
+// onAddOrUpdateEndpointSlice indexes every ready address in the slice, keyed by both the bare
+// address (for clients that dial a headless-service pod IP directly) and address:port (for
+// clients that dial a backing pod's IP:port), under the owning service's name@namespace. The
+// service's own ClusterIP is not indexed here.
+func onAddOrUpdateEndpointSlice(obj interface{}, ipToServiceEndpoints, ipToServiceAndNamespace, serviceAndNamespaceToHeadless *sync.Map) {
+	slice := obj.(*discv1.EndpointSlice)
+	serviceName, ok := slice.Labels[labelServiceName]
+	if !ok || serviceName == "" {
+		return
+	}
+	serviceAndNamespace := attachNamespace(serviceName, slice.Namespace)
+	_, isHeadless := serviceAndNamespaceToHeadless.Load(serviceAndNamespace)
+
+	var ports []string
+	for _, port := range slice.Ports {
+		if port.Port != nil {
+			ports = append(ports, strconv.Itoa(int(*port.Port)))
+		}
+	}
+
+	for _, endpoint := range slice.Endpoints {
+		if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+			continue
+		}
+		for _, address := range endpoint.Addresses {
+			ipToServiceEndpoints.Store(address, serviceAndNamespace)
+			for _, port := range ports {
+				ipToServiceEndpoints.Store(address+":"+port, serviceAndNamespace)
+			}
+			if isHeadless {
+				// clients of a headless service dial the pod IP directly (via per-pod DNS A
+				// records), so GetWorkloadAndNamespaceByIP needs to find the service there too
+				ipToServiceAndNamespace.Store(address, serviceAndNamespace)
+			}
+		}
+	}
+}
+
+func onDeleteEndpointSlice(obj interface{}, ipToServiceEndpoints *sync.Map, deleter Deleter) {
+	slice := obj.(*discv1.EndpointSlice)
+	serviceName, ok := slice.Labels[labelServiceName]
+	if !ok || serviceName == "" {
+		return
+	}
+	serviceAndNamespace := attachNamespace(serviceName, slice.Namespace)
+
+	var ports []string
+	for _, port := range slice.Ports {
+		if port.Port != nil {
+			ports = append(ports, strconv.Itoa(int(*port.Port)))
+		}
+	}
+
+	for _, endpoint := range slice.Endpoints {
+		for _, address := range endpoint.Addresses {
+			deleter.DeleteWithDelay(ipToServiceEndpoints, address, serviceAndNamespace)
+			for _, port := range ports {
+				deleter.DeleteWithDelay(ipToServiceEndpoints, address+":"+port, serviceAndNamespace)
+			}
+		}
+	}
+}
+
+// EndpointSliceWatcher keeps ipToServiceEndpoints up to date from discovery.k8s.io/v1
+// EndpointSlice objects, replacing the need to re-derive service membership from
+// Service.Spec.Selector and per-workload label sets on every mapping pass.
+type EndpointSliceWatcher struct {
+	ipToServiceEndpoints          *sync.Map
+	ipToServiceAndNamespace       *sync.Map
+	serviceAndNamespaceToHeadless *sync.Map
+	logger                        *zap.Logger
+	informer                      cache.SharedIndexInformer
+	deleter                       Deleter
+}
+
+func NewEndpointSliceWatcher(logger *zap.Logger, informer cache.SharedIndexInformer, ipToServiceAndNamespace, serviceAndNamespaceToHeadless *sync.Map, deleter Deleter) *EndpointSliceWatcher {
+	return &EndpointSliceWatcher{
+		ipToServiceEndpoints:          &sync.Map{},
+		ipToServiceAndNamespace:       ipToServiceAndNamespace,
+		serviceAndNamespaceToHeadless: serviceAndNamespaceToHeadless,
+		logger:                        logger,
+		informer:                      informer,
+		deleter:                       deleter,
+	}
+}
+
+func (w *EndpointSliceWatcher) Run(stopCh chan struct{}) {
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.logger.Debug("list and watch for endpointslices: ADD")
+			onAddOrUpdateEndpointSlice(obj, w.ipToServiceEndpoints, w.ipToServiceAndNamespace, w.serviceAndNamespaceToHeadless)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			w.logger.Debug("list and watch for endpointslices: UPDATE")
+			onAddOrUpdateEndpointSlice(newObj, w.ipToServiceEndpoints, w.ipToServiceAndNamespace, w.serviceAndNamespaceToHeadless)
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.logger.Debug("list and watch for endpointslices: DELETE")
+			onDeleteEndpointSlice(obj, w.ipToServiceEndpoints, w.deleter)
+		},
+	})
+
+	go w.informer.Run(stopCh)
+}
+
+func (w *EndpointSliceWatcher) WaitForCacheSync(stopCh chan struct{}) {
+	if !cache.WaitForNamedCacheSync("endpointSliceWatcher", stopCh, w.informer.HasSynced) {
+		w.logger.Fatal("timed out waiting for kubernetes endpointslice watcher caches to sync")
+	}
+
+	w.logger.Info("EndpointSliceWatcher: Cache synced")
+}
+
 type ServiceToWorkloadMapper struct {
 	serviceAndNamespaceToSelectors *sync.Map
 	workloadAndNamespaceToLabels   *sync.Map
@@ -475,7 +1137,7 @@ func (m *ServiceToWorkloadMapper) MapServiceToWorkload() {
 			m.serviceToWorkload.Store(serviceAndNamespace, workloads[0])
 		} else {
 			m.logger.Debug("No workload found for service", zap.String("service", serviceAndNamespace))
-			m.deleter.DeleteWithDelay(m.serviceToWorkload, serviceAndNamespace)
+			m.deleter.DeleteWithDelay(m.serviceToWorkload, serviceAndNamespace, nil)
 		}
 		return true
 	})
@@ -499,6 +1161,190 @@ func (m *ServiceToWorkloadMapper) Start(stopCh chan struct{}) {
 	}()
 }
 
+// discoveryFilterConfig scopes the resolver's informers down from a cluster-wide list/watch to
+// a namespace, a pod label selector, and/or the local node, analogous to Istio's
+// DiscoveryNamespacesFilter. This both bounds memory on large clusters and lets the agent's
+// ServiceAccount be granted RBAC for a single namespace or "list pods on my node" instead of
+// cluster-wide pods/services access.
+type discoveryFilterConfig struct {
+	// namespace restricts informers to a single namespace; empty means cluster-wide.
+	namespace string
+	// excludeNamespaces are skipped by the pod watcher even though they fall within
+	// namespace/cluster-wide scope; services and EndpointSlices in them are still indexed.
+	excludeNamespaces map[string]struct{}
+	// podLabelSelector is applied as a LabelSelector on the pod informer's list/watch calls.
+	podLabelSelector string
+	// nodeName, typically populated from the downward API in DaemonSet mode, restricts the pod
+	// informer to pods scheduled on this node via the spec.nodeName field selector.
+	nodeName string
+}
+
+func loadDiscoveryFilterConfigFromEnv() discoveryFilterConfig {
+	cfg := discoveryFilterConfig{
+		namespace:        os.Getenv(discoveryNamespaceEnvKey),
+		podLabelSelector: os.Getenv(discoveryPodLabelSelectorEnvKey),
+		nodeName:         os.Getenv(discoveryNodeNameEnvKey),
+	}
+	if raw := os.Getenv(discoveryExcludeNamespacesEnvKey); raw != "" {
+		cfg.excludeNamespaces = make(map[string]struct{})
+		for _, ns := range strings.Split(raw, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				cfg.excludeNamespaces[ns] = struct{}{}
+			}
+		}
+	}
+	return cfg
+}
+
+// tweakPodListOptions applies the pod label selector and, in DaemonSet mode, the per-node field
+// selector. It is only safe to use for the pod informer: other resources (Services,
+// EndpointSlices) don't carry a pod label set or a spec.nodeName field.
+func (c discoveryFilterConfig) tweakPodListOptions(options *metav1.ListOptions) {
+	if c.podLabelSelector != "" {
+		options.LabelSelector = c.podLabelSelector
+	}
+	if c.nodeName != "" {
+		options.FieldSelector = "spec.nodeName=" + c.nodeName
+	}
+}
+
+// ErrIPOutOfResolverScope is returned by GetWorkloadAndNamespaceByIP when an IP belongs to a pod
+// in a namespace the resolver was configured to ignore, so callers can distinguish "filtered out
+// on purpose" from a genuine resolution miss.
+type ErrIPOutOfResolverScope struct {
+	Namespace string
+}
+
+func (e *ErrIPOutOfResolverScope) Error() string {
+	return fmt.Sprintf("ip belongs to namespace %q, which is outside the resolver's configured scope", e.Namespace)
+}
+
+// newScopedSharedInformerFactory builds a SharedInformerFactory restricted to namespace (when
+// non-empty) and, when tweak is non-nil, with the given per-list/watch option tweak applied.
+// An empty namespace preserves the previous cluster-wide behavior.
+func newScopedSharedInformerFactory(clientset kubernetes.Interface, namespace string, tweak func(*metav1.ListOptions)) informers.SharedInformerFactory {
+	var opts []informers.SharedInformerOption
+	if namespace != "" {
+		opts = append(opts, informers.WithNamespace(namespace))
+	}
+	if tweak != nil {
+		opts = append(opts, informers.WithTweakListOptions(tweak))
+	}
+	return informers.NewSharedInformerFactoryWithOptions(clientset, 0, opts...)
+}
+
+// newEksResolverFromClientset builds a fully wired eksResolver (informers started, caches
+// synced) around an already-constructed clientset. It is the common path shared by the
+// package-level singleton (getEksResolver, one local-cluster kubeconfig) and the
+// MultiClusterResolver (one instance per registered remote cluster, each with its own
+// clientset), so cross-cluster support didn't require duplicating the informer wiring.
+func newEksResolverFromClientset(logger *zap.Logger, clientset kubernetes.Interface, filterCfg discoveryFilterConfig) *eksResolver {
+	// Services and EndpointSlices only get the namespace restriction: a label/node
+	// selector tuned for pods doesn't carry meaning for them, and RBAC for "list services
+	// in my namespace" is already a large reduction from cluster-wide.
+	sharedInformerFactory := newScopedSharedInformerFactory(clientset, filterCfg.namespace, nil)
+	serviceInformer := sharedInformerFactory.Core().V1().Services().Informer()
+
+	// Pods additionally get the label selector and, in DaemonSet mode, the
+	// spec.nodeName=$NODE_NAME field selector so a node-local agent never caches pods
+	// scheduled elsewhere in the cluster.
+	podInformerFactory := newScopedSharedInformerFactory(clientset, filterCfg.namespace, filterCfg.tweakPodListOptions)
+	podInformer := podInformerFactory.Core().V1().Pods().Informer()
+
+	timedDeleter := &TimedDeleter{Delay: deletionDelay}
+	podWatcher := NewFilteredPodWatcher(logger, podInformer, timedDeleter, filterCfg.excludeNamespaces)
+	serviceWatcher := NewServiceWatcher(logger, serviceInformer, timedDeleter)
+	serviceToWorkload := &sync.Map{}
+
+	snapshotSync := newSnapshotter(logger, os.Getenv(resolverSnapshotPathEnvKey), defaultSnapshotInterval)
+	indexes := resolverIndexes{
+		ipToPod:                      podWatcher.ipToPod,
+		podToWorkloadAndNamespace:    podWatcher.podToWorkloadAndNamespace,
+		workloadAndNamespaceToLabels: podWatcher.workloadAndNamespaceToLabels,
+		serviceToWorkload:            serviceToWorkload,
+	}
+	var warmStartedPodIPs map[string][]string
+	if snap, ok := snapshotSync.load(); ok {
+		// populate the indexes before the informers have listed anything so the first few
+		// seconds after a restart don't resolve every remote IP to UnknownRemoteService
+		warmStartedPodIPs = warmStart(indexes, snap)
+	}
+
+	safeStopCh := &safeChannel{ch: make(chan struct{}), closed: false}
+	// initialize the pod and service watchers for the cluster
+	podWatcher.Run(safeStopCh.ch)
+	serviceWatcher.Run(safeStopCh.ch)
+	// wait for caches to sync (for once) so that clients knows about the pods and services in the cluster
+	podWatcher.WaitForCacheSync(safeStopCh.ch)
+	serviceWatcher.WaitForCacheSync(safeStopCh.ch)
+
+	reconcileWarmStart(logger, podInformer, podWatcher.podToWorkloadAndNamespace, podWatcher.ipToPod, warmStartedPodIPs, safeStopCh.ch)
+	snapshotSync.start(safeStopCh.ch, podInformer, indexes)
+
+	var ipToServiceEndpoints *sync.Map
+	if useListAndWatchEndpointSlices() {
+		endpointSliceInformer := sharedInformerFactory.Discovery().V1().EndpointSlices().Informer()
+		endpointSliceWatcher := NewEndpointSliceWatcher(logger, endpointSliceInformer, serviceWatcher.ipToServiceAndNamespace, serviceWatcher.serviceAndNamespaceToHeadless, timedDeleter)
+		endpointSliceWatcher.Run(safeStopCh.ch)
+		endpointSliceWatcher.WaitForCacheSync(safeStopCh.ch)
+		ipToServiceEndpoints = endpointSliceWatcher.ipToServiceEndpoints
+	}
+
+	if ipToServiceEndpoints == nil {
+		// fall back to the legacy 90-second selector-subset mapper when EndpointSlice
+		// informers are disabled (e.g. clusters without discovery.k8s.io/v1)
+		serviceToWorkloadMapper := NewServiceToWorkloadMapper(serviceWatcher.serviceAndNamespaceToSelectors, podWatcher.workloadAndNamespaceToLabels, serviceToWorkload, logger, timedDeleter)
+		serviceToWorkloadMapper.Start(safeStopCh.ch)
+	}
+
+	podResources := NewPodResourcesStore(logger, os.Getenv(podResourcesSocketPathEnvKey), defaultPodResourcesRefreshInterval)
+	podResources.Start(safeStopCh.ch)
+
+	resolver := &eksResolver{
+		logger:                         logger,
+		clientset:                      clientset,
+		ipToServiceAndNamespace:        serviceWatcher.ipToServiceAndNamespace,
+		serviceAndNamespaceToSelectors: serviceWatcher.serviceAndNamespaceToSelectors,
+		serviceAndNamespaceToHeadless:  serviceWatcher.serviceAndNamespaceToHeadless,
+		ipToPod:                        podWatcher.ipToPod,
+		podToWorkloadAndNamespace:      podWatcher.podToWorkloadAndNamespace,
+		workloadAndNamespaceToLabels:   podWatcher.workloadAndNamespaceToLabels,
+		serviceToWorkload:              serviceToWorkload,
+		ipToServiceEndpoints:           ipToServiceEndpoints,
+		ipToExcludedNamespace:          podWatcher.ipToExcludedNamespace,
+		workloadPodCount:               podWatcher.workloadPodCount,
+		podResources:                   podResources,
+		safeStopCh:                     safeStopCh,
+		telemetry:                      newResolverTelemetry(),
+	}
+
+	go resolver.debugPrint()
+	go resolver.reportCacheSizePeriodically(safeStopCh.ch)
+	return resolver
+}
+
+// reportCacheSizePeriodically refreshes the awsapm.resolver.cache.size gauge every 5 minutes, the
+// same cadence as debugPrint's own diagnostic logging, in a loop of its own.
+func (e *eksResolver) reportCacheSizePeriodically(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(5 * time.Minute):
+			e.telemetry.recordCacheSize(context.Background(), "eks", syncMapLen(e.ipToPod))
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func syncMapLen(m *sync.Map) int64 {
+	var n int64
+	m.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
 func getEksResolver(logger *zap.Logger) subResolver {
 	once.Do(func() {
 		config, err := clientcmd.BuildConfigFromFlags("", "")
@@ -514,45 +1360,19 @@ func getEksResolver(logger *zap.Logger) subResolver {
 		// add a time jitter of 10 seconds
 		jitterSleep(10)
 
-		sharedInformerFactory := informers.NewSharedInformerFactory(clientset, 0)
-		podInformer := sharedInformerFactory.Core().V1().Pods().Informer()
-		serviceInformer := sharedInformerFactory.Core().V1().Services().Informer()
-
-		timedDeleter := &TimedDeleter{Delay: deletionDelay}
-		podWatcher := NewPodWatcher(logger, podInformer, timedDeleter)
-		serviceWatcher := NewServiceWatcher(logger, serviceInformer, timedDeleter)
-
-		safeStopCh := &safeChannel{ch: make(chan struct{}), closed: false}
-		// initialize the pod and service watchers for the cluster
-		podWatcher.Run(safeStopCh.ch)
-		serviceWatcher.Run(safeStopCh.ch)
-		// wait for caches to sync (for once) so that clients knows about the pods and services in the cluster
-		podWatcher.WaitForCacheSync(safeStopCh.ch)
-		serviceWatcher.WaitForCacheSync(safeStopCh.ch)
-
-		serviceToWorkload := &sync.Map{}
-		serviceToWorkloadMapper := NewServiceToWorkloadMapper(serviceWatcher.serviceAndNamespaceToSelectors, podWatcher.workloadAndNamespaceToLabels, serviceToWorkload, logger, timedDeleter)
-		serviceToWorkloadMapper.Start(safeStopCh.ch)
-
-		instance = &eksResolver{
-			logger:                         logger,
-			clientset:                      clientset,
-			ipToServiceAndNamespace:        serviceWatcher.ipToServiceAndNamespace,
-			serviceAndNamespaceToSelectors: serviceWatcher.serviceAndNamespaceToSelectors,
-			ipToPod:                        podWatcher.ipToPod,
-			podToWorkloadAndNamespace:      podWatcher.podToWorkloadAndNamespace,
-			workloadAndNamespaceToLabels:   podWatcher.workloadAndNamespaceToLabels,
-			serviceToWorkload:              serviceToWorkload,
-			workloadPodCount:               podWatcher.workloadPodCount,
-			safeStopCh:                     safeStopCh,
-		}
-
-		go instance.debugPrint()
+		instance = newEksResolverFromClientset(logger, clientset, loadDiscoveryFilterConfigFromEnv())
 	})
 
 	return instance
 }
 
+// useListAndWatchEndpointSlices reports whether the EndpointSlice-based service-to-workload
+// mapping should be used in place of the periodic selector-subset mapper. Clusters that don't
+// serve discovery.k8s.io/v1, or operators who want the old behavior, can set the env var to "false".
+func useListAndWatchEndpointSlices() bool {
+	return strings.ToLower(os.Getenv(useListAndWatchEndpointSlicesEnvKey)) != "false"
+}
+
 func (e *eksResolver) Stop(_ context.Context) error {
 	e.safeStopCh.Close()
 	return nil
@@ -560,6 +1380,10 @@ func (e *eksResolver) Stop(_ context.Context) error {
 
 // add a method to eksResolver
 func (e *eksResolver) GetWorkloadAndNamespaceByIP(ip string) (string, string, error) {
+	defer func(start time.Time) {
+		e.telemetry.recordLookup(context.Background(), "eks", time.Since(start))
+	}(time.Now())
+
 	var workload, namespace string
 	if podKey, ok := e.ipToPod.Load(ip); ok {
 		pod := podKey.(string)
@@ -577,9 +1401,44 @@ func (e *eksResolver) GetWorkloadAndNamespaceByIP(ip string) (string, string, er
 		}
 	}
 
+	if e.ipToExcludedNamespace != nil {
+		if nsKey, ok := e.ipToExcludedNamespace.Load(ip); ok {
+			return "", "", &ErrIPOutOfResolverScope{Namespace: nsKey.(string)}
+		}
+	}
+
 	return "", "", errors.New("no EKS workload found for ip: " + ip)
 }
 
+// GetWorkloadAndNamespaceByServiceIP resolves a pod endpoint address - either address:port or, for
+// headless services, a bare pod IP - against the EndpointSlice-derived ipToServiceEndpoints index,
+// without relying on the Service.Spec.Selector subset match against cached workload labels. Despite
+// the name, it never resolves a Service's own ClusterIP: onAddOrUpdateEndpointSlice only ever
+// populates ipToServiceEndpoints with endpoint (pod) addresses, never the Service's ClusterIP - a
+// clusterIP:port lookup always misses here and falls through to the caller's
+// GetWorkloadAndNamespaceByIP(ip)/ipToServiceAndNamespace fallback instead. It is only populated
+// when EndpointSlice informers are enabled; callers should fall back to GetWorkloadAndNamespaceByIP
+// otherwise.
+func (e *eksResolver) GetWorkloadAndNamespaceByServiceIP(ipOrIPPort string) (string, string, error) {
+	if e.ipToServiceEndpoints == nil {
+		return "", "", errors.New("endpointslice-based resolution is disabled")
+	}
+
+	serviceKey, ok := e.ipToServiceEndpoints.Load(ipOrIPPort)
+	if !ok {
+		return "", "", errors.New("no EKS service endpoint found for pod address: " + ipOrIPPort)
+	}
+
+	serviceAndNamespace := serviceKey.(string)
+	if workloadKey, ok := e.serviceToWorkload.Load(serviceAndNamespace); ok {
+		workload, namespace := extractResourceAndNamespace(workloadKey.(string))
+		return workload, namespace, nil
+	}
+
+	service, namespace := extractResourceAndNamespace(serviceAndNamespace)
+	return service, namespace, nil
+}
+
 func printSyncMap(name string, m *sync.Map, logger *zap.Logger) {
 	logger.Debug("", zap.String("MapName", name))
 	m.Range(func(key, value interface{}) bool {
@@ -624,11 +1483,50 @@ func (e *eksResolver) debug() {
 	e.logger.Debug("end debug print")
 }
 
+// k8sServiceDNSNamePattern matches the cluster-internal DNS names kubelet/CoreDNS assign to
+// Services: `<service>.<namespace>.svc[.cluster-domain]` for the Service itself, and
+// `<pod-hostname>.<service>.<namespace>.svc[.cluster-domain]` for the per-pod A records that a
+// headless Service's StatefulSet pods get (the only way clients of e.g. Kafka or Cassandra
+// address a specific broker/node directly).
+var k8sServiceDNSNamePattern = regexp.MustCompile(`^(?:[a-zA-Z0-9-]+\.)?([a-zA-Z0-9-]+)\.([a-zA-Z0-9-]+)\.svc(?:\.[a-zA-Z0-9.-]+)?$`)
+
+// resolveHeadlessServiceDNSName recognizes valueStr as a Kubernetes-generated DNS name naming a
+// headless Service (or one of its per-pod A records) and, if so, returns the Service's name and
+// namespace. It returns ok=false for DNS names belonging to ordinary (non-headless) Services, so
+// callers keep attributing those to the backing pod's workload as before.
+func (e *eksResolver) resolveHeadlessServiceDNSName(valueStr string) (service, namespace string, ok bool) {
+	match := k8sServiceDNSNamePattern.FindStringSubmatch(valueStr)
+	if match == nil {
+		return "", "", false
+	}
+
+	service, namespace = match[1], match[2]
+	if _, isHeadless := e.serviceAndNamespaceToHeadless.Load(attachNamespace(service, namespace)); !isHeadless {
+		return "", "", false
+	}
+	return service, namespace, true
+}
+
 func (e *eksResolver) Process(attributes, resourceAttributes pcommon.Map) error {
+	if e.podResources != nil && e.podResources.Process(attributes, resourceAttributes) {
+		return nil
+	}
+
 	if value, ok := attributes.Get(AttributeRemoteService); ok {
 		valueStr := value.AsString()
+		if service, namespace, ok := e.resolveHeadlessServiceDNSName(valueStr); ok {
+			attributes.PutStr(AttributeRemoteService, service)
+			attributes.PutStr(AttributeRemoteNamespace, namespace)
+			return nil
+		}
+
 		ipStr := ""
-		if ip, _, ok := extractIPPort(valueStr); ok {
+		if ip, port, ok := extractIPPort(valueStr); ok {
+			if workload, namespace, err := e.GetWorkloadAndNamespaceByServiceIP(ip + ":" + port); err == nil {
+				attributes.PutStr(AttributeRemoteService, workload)
+				attributes.PutStr(AttributeRemoteNamespace, namespace)
+				return nil
+			}
 			if workload, namespace, err := e.GetWorkloadAndNamespaceByIP(valueStr); err == nil {
 				attributes.PutStr(AttributeRemoteService, workload)
 				attributes.PutStr(AttributeRemoteNamespace, namespace)
@@ -658,26 +1556,23 @@ func isIP(ipString string) bool {
 	return ip != nil
 }
 
-const IP_PORT_PATTERN = `^(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}):(\d+)$`
-
-var ipPortRegex = regexp.MustCompile(IP_PORT_PATTERN)
-
+// extractIPPort splits a host:port value into a normalized IP and its port, accepting IPv4
+// (`1.2.3.4:80`), bracketed IPv6 (`[2001:db8::1]:8080`), and IPv4-mapped IPv6 forms.
+// net.SplitHostPort already knows how to strip IPv6 brackets; we additionally validate that the
+// host really is an IP (not a hostname) and canonicalize it so the same address always produces
+// the same ipToPod/ipToServiceAndNamespace key regardless of which textual form it arrived in.
 func extractIPPort(ipPort string) (string, string, bool) {
-	match := ipPortRegex.MatchString(ipPort)
-
-	if !match {
+	host, port, err := net.SplitHostPort(ipPort)
+	if err != nil {
 		return "", "", false
 	}
 
-	result := ipPortRegex.FindStringSubmatch(ipPort)
-	if len(result) != 3 {
+	ip := net.ParseIP(host)
+	if ip == nil {
 		return "", "", false
 	}
 
-	ip := result[1]
-	port := result[2]
-
-	return ip, port, true
+	return ip.String(), port, true
 }
 
 func getHostNetworkPorts(pod *corev1.Pod) []string {
@@ -736,4 +1631,4 @@ func (h *eksHostedInAttributeResolver) Process(attributes, resourceAttributes pc
 
 func (h *eksHostedInAttributeResolver) Stop(ctx context.Context) error {
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,165 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// MultiClusterResolver fans a single eksResolver out into one per EKS cluster, modeled on
+// Istio's serviceregistry/aggregate: a trace's peer.ip frequently belongs to a pod in a
+// different cluster when callers cross a Transit-Gateway-peered VPC or a mesh boundary, and a
+// single-cluster eksResolver has no way to resolve that. Unlike the package-level singleton
+// built by getEksResolver, a MultiClusterResolver owns an arbitrary number of eksResolver
+// instances and supports adding/removing clusters at runtime.
+type MultiClusterResolver struct {
+	mu                sync.RWMutex
+	logger            *zap.Logger
+	clusters          map[string]*eksResolver
+	clusterAccountIDs map[string]string // populated for clusters registered through RegisterRemote
+}
+
+// NewMultiClusterResolver returns an empty aggregate resolver; clusters are added with Register.
+func NewMultiClusterResolver(logger *zap.Logger) *MultiClusterResolver {
+	return &MultiClusterResolver{
+		logger:   logger,
+		clusters: make(map[string]*eksResolver),
+	}
+}
+
+// Register builds a clientset from the given kubeconfig path (empty path means in-cluster
+// config) and starts a dedicated eksResolver - informers, watchers, and all - for clusterName.
+// Registering a name that's already present replaces it, stopping the old resolver once the new
+// one is in place.
+func (m *MultiClusterResolver) Register(clusterName, kubeconfigPath string) error {
+	if clusterName == "" {
+		return errors.New("clusterName must not be empty")
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to build config for cluster %q: %w", clusterName, err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create client for cluster %q: %w", clusterName, err)
+	}
+
+	resolver := newEksResolverFromClientset(m.logger, clientset, loadDiscoveryFilterConfigFromEnv())
+
+	m.mu.Lock()
+	old, existed := m.clusters[clusterName]
+	m.clusters[clusterName] = resolver
+	delete(m.clusterAccountIDs, clusterName)
+	m.mu.Unlock()
+
+	if existed {
+		if err := old.Stop(context.Background()); err != nil {
+			m.logger.Error("failed to stop replaced cluster resolver", zap.String("cluster", clusterName), zap.Error(err))
+		}
+	}
+
+	m.logger.Info("registered EKS cluster with multi-cluster resolver", zap.String("cluster", clusterName))
+	return nil
+}
+
+// Deregister stops and removes clusterName's resolver. It is a no-op if the cluster isn't
+// currently registered.
+func (m *MultiClusterResolver) Deregister(clusterName string) error {
+	m.mu.Lock()
+	resolver, ok := m.clusters[clusterName]
+	delete(m.clusters, clusterName)
+	delete(m.clusterAccountIDs, clusterName)
+	m.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return resolver.Stop(context.Background())
+}
+
+// snapshot returns a stable copy of the registered clusters so callers can iterate without
+// holding the lock for the duration of (potentially slow) per-cluster lookups.
+func (m *MultiClusterResolver) snapshot() map[string]*eksResolver {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	clusters := make(map[string]*eksResolver, len(m.clusters))
+	for name, resolver := range m.clusters {
+		clusters[name] = resolver
+	}
+	return clusters
+}
+
+// GetWorkloadAndNamespaceByIP fans out to every registered cluster's resolver and returns the
+// first hit, along with the name of the cluster it was found in. Order across clusters is
+// unspecified, so callers attaching traces that could plausibly resolve in more than one cluster
+// (e.g. overlapping CIDRs in unpeered VPCs) should register narrower, non-overlapping clusters.
+func (m *MultiClusterResolver) GetWorkloadAndNamespaceByIP(ip string) (workload, namespace, clusterName string, err error) {
+	for name, resolver := range m.snapshot() {
+		if workload, namespace, err = resolver.GetWorkloadAndNamespaceByIP(ip); err == nil {
+			return workload, namespace, name, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("no EKS workload found for ip %s in any registered cluster", ip)
+}
+
+// Process mirrors eksResolver.Process's IP and IP:port resolution across every registered
+// cluster, except the resolved cluster is additionally attached so cross-cluster callers stay
+// disambiguated from same-cluster ones. Values that are neither an IP nor an IP:port are left
+// untouched; the container/device ID and headless-service DNS name paths of eksResolver.Process
+// are not applied here.
+func (m *MultiClusterResolver) Process(attributes, resourceAttributes pcommon.Map) error {
+	value, ok := attributes.Get(AttributeRemoteService)
+	if !ok {
+		return nil
+	}
+
+	valueStr := value.AsString()
+	ipStr := ""
+	if ip, _, isIPPort := extractIPPort(valueStr); isIPPort {
+		ipStr = ip
+	} else if isIP(valueStr) {
+		ipStr = valueStr
+	}
+	if ipStr == "" {
+		return nil
+	}
+
+	workload, namespace, clusterName, err := m.GetWorkloadAndNamespaceByIP(ipStr)
+	if err != nil {
+		m.logger.Debug("failed to Process ip across registered clusters", zap.String("ip", ipStr), zap.Error(err))
+		attributes.PutStr(AttributeRemoteService, "UnknownRemoteService")
+		return nil
+	}
+
+	attributes.PutStr(AttributeRemoteService, workload)
+	attributes.PutStr(AttributeRemoteNamespace, namespace)
+	attributes.PutStr(AttributeRemoteClusterName, clusterName)
+	if accountID, ok := m.accountID(clusterName); ok {
+		attributes.PutStr(AttributeRemoteAccountId, accountID)
+	}
+	return nil
+}
+
+// Stop stops every registered cluster's resolver, collecting (rather than short-circuiting on)
+// any individual failures so one bad cluster doesn't block the others from shutting down.
+func (m *MultiClusterResolver) Stop(ctx context.Context) error {
+	var errs []error
+	for name, resolver := range m.snapshot() {
+		if err := resolver.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("cluster %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
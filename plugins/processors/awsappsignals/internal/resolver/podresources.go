@@ -0,0 +1,198 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	semconv "go.opentelemetry.io/collector/semconv/v1.17.0"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	podresourcesapi "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+const (
+	// defaultPodResourcesSocket is where kubelet exposes the PodResources gRPC API on every node;
+	// it's a unix domain socket, so there's no TLS/auth to configure beyond the socket's own
+	// filesystem permissions (typically root-only, matching the agent's DaemonSet privileges).
+	defaultPodResourcesSocket = "/var/lib/kubelet/pod-resources/kubelet.sock"
+
+	defaultPodResourcesRefreshInterval = 30 * time.Second
+	podResourcesDialTimeout            = 5 * time.Second
+
+	// deviceIDAttributeKey is the attribute callers set to identify a specific allocated device
+	// (e.g. a GPU UUID) when a signal has no peer IP to resolve through the apiserver index.
+	deviceIDAttributeKey = "device.id"
+)
+
+// workloadAttribution is what PodResourcesStore resolves a container ID or device ID to.
+type workloadAttribution struct {
+	pod           string
+	namespace     string
+	workload      string
+	containerName string
+}
+
+// PodResourcesStore is an on-node fast path for attributing signals that carry a container ID or
+// a device identifier (e.g. a GPU UUID) but no peer IP - host-network server processes, GPU
+// kernels reported by a device plugin's own instrumentation, and similar cases where the
+// apiserver-index-based eksResolver has nothing to key off of. It polls kubelet's PodResources
+// gRPC API (ListPodResources) on a fixed interval and keeps the result in memory; unlike the
+// eksResolver's informer-backed indexes it isn't watch-based, since PodResources exposes no watch
+// RPC as of this kubelet version.
+type PodResourcesStore struct {
+	logger          *zap.Logger
+	socketPath      string
+	refreshInterval time.Duration
+
+	mu                sync.RWMutex
+	containerIDToInfo map[string]workloadAttribution
+	deviceIDToInfo    map[string]workloadAttribution
+
+	socketUnavailable bool // logged once per outage, rather than on every refresh tick
+}
+
+// NewPodResourcesStore returns a store that will poll socketPath every refreshInterval once
+// Start is called. A zero-value socketPath or refreshInterval falls back to kubelet's well-known
+// socket path and a 30 second interval respectively.
+func NewPodResourcesStore(logger *zap.Logger, socketPath string, refreshInterval time.Duration) *PodResourcesStore {
+	if socketPath == "" {
+		socketPath = defaultPodResourcesSocket
+	}
+	if refreshInterval <= 0 {
+		refreshInterval = defaultPodResourcesRefreshInterval
+	}
+
+	return &PodResourcesStore{
+		logger:            logger,
+		socketPath:        socketPath,
+		refreshInterval:   refreshInterval,
+		containerIDToInfo: make(map[string]workloadAttribution),
+		deviceIDToInfo:    make(map[string]workloadAttribution),
+	}
+}
+
+// Start runs the refresh loop until stopCh is closed. The first refresh happens synchronously so
+// that a lookup immediately after Start returns has a chance of succeeding; a kubelet that isn't
+// reachable yet (e.g. the agent starts before kubelet finishes its own startup) holds Start for at
+// most podResourcesDialTimeout - it's logged and retried on the next tick like any other refresh
+// failure.
+func (s *PodResourcesStore) Start(stopCh <-chan struct{}) {
+	s.refresh()
+
+	go func() {
+		ticker := time.NewTicker(s.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *PodResourcesStore) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), podResourcesDialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "unix://"+s.socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		if !s.socketUnavailable {
+			s.logger.Warn("pod-resources socket unavailable, workload attribution via container/device id is disabled until it reappears",
+				zap.String("socket", s.socketPath), zap.Error(err))
+			s.socketUnavailable = true
+		}
+		return
+	}
+	defer conn.Close()
+
+	client := podresourcesapi.NewPodResourcesListerClient(conn)
+	resp, err := client.List(ctx, &podresourcesapi.ListPodResourcesRequest{})
+	if err != nil {
+		s.logger.Warn("failed to list pod resources from kubelet", zap.Error(err))
+		return
+	}
+	s.socketUnavailable = false
+
+	containerIDToInfo := make(map[string]workloadAttribution)
+	deviceIDToInfo := make(map[string]workloadAttribution)
+
+	for _, podResources := range resp.GetPodResources() {
+		workload, err := extractWorkloadNameFromPodName(podResources.GetName())
+		if err != nil {
+			workload = podResources.GetName()
+		}
+
+		for _, container := range podResources.GetContainers() {
+			info := workloadAttribution{
+				pod:           podResources.GetName(),
+				namespace:     podResources.GetNamespace(),
+				workload:      workload,
+				containerName: container.GetName(),
+			}
+
+			if id := container.GetContainerId(); id != "" {
+				containerIDToInfo[id] = info
+			}
+			for _, device := range container.GetDevices() {
+				for _, deviceID := range device.GetDeviceIds() {
+					deviceIDToInfo[deviceID] = info
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.containerIDToInfo = containerIDToInfo
+	s.deviceIDToInfo = deviceIDToInfo
+	s.mu.Unlock()
+}
+
+func (s *PodResourcesStore) lookupByContainerID(containerID string) (workloadAttribution, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info, ok := s.containerIDToInfo[containerID]
+	return info, ok
+}
+
+func (s *PodResourcesStore) lookupByDeviceID(deviceID string) (workloadAttribution, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info, ok := s.deviceIDToInfo[deviceID]
+	return info, ok
+}
+
+// Process resolves AttributeRemoteService/AttributeRemoteNamespace from a container.id or
+// device.id attribute, without touching anything IP-based. It returns false when neither
+// attribute is present or neither resolves, so callers can compose it ahead of the (slower,
+// apiserver-index-backed) eksResolver.Process and only fall back to that when this returns false.
+func (s *PodResourcesStore) Process(attributes, resourceAttributes pcommon.Map) bool {
+	if containerID, ok := attributes.Get(semconv.AttributeContainerID); ok {
+		if info, found := s.lookupByContainerID(containerID.AsString()); found {
+			attributes.PutStr(AttributeRemoteService, info.workload)
+			attributes.PutStr(AttributeRemoteNamespace, info.namespace)
+			return true
+		}
+	}
+
+	if deviceID, ok := attributes.Get(deviceIDAttributeKey); ok {
+		if info, found := s.lookupByDeviceID(deviceID.AsString()); found {
+			attributes.PutStr(AttributeRemoteService, info.workload)
+			attributes.PutStr(AttributeRemoteNamespace, info.namespace)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,170 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	authtoken "sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+// RemoteClusterTarget names an EKS cluster that isn't reachable through the agent's ambient
+// credentials or kubeconfig - typically because it lives in a different AWS account, or a
+// different region, than the instance the agent runs on - along with the role MultiClusterResolver
+// should assume to reach it.
+type RemoteClusterTarget struct {
+	ClusterName   string
+	Region        string
+	AssumeRoleARN string
+	ExternalID    string // optional, forwarded to sts:AssumeRole when the target account requires it
+}
+
+// RegisterRemote assumes AssumeRoleARN, describes ClusterName to obtain its apiserver endpoint
+// and CA bundle, and starts a dedicated eksResolver against it - the same as Register, except
+// authenticated as the assumed role instead of the ambient credentials. The resolver it starts is
+// independent of every other registered cluster's: it has its own informers, its own safeStopCh,
+// and Deregistering or replacing it (by calling RegisterRemote again with the same ClusterName)
+// never touches the others.
+func (m *MultiClusterResolver) RegisterRemote(ctx context.Context, target RemoteClusterTarget) error {
+	if target.ClusterName == "" {
+		return errors.New("target.ClusterName must not be empty")
+	}
+	if target.AssumeRoleARN == "" {
+		return fmt.Errorf("target %q: AssumeRoleARN must not be empty", target.ClusterName)
+	}
+
+	clientset, accountID, err := newAssumedRoleEKSClientset(target)
+	if err != nil {
+		return fmt.Errorf("failed to build client for remote cluster %q: %w", target.ClusterName, err)
+	}
+
+	resolver := newEksResolverFromClientset(m.logger, clientset, loadDiscoveryFilterConfigFromEnv())
+
+	m.mu.Lock()
+	old, existed := m.clusters[target.ClusterName]
+	m.clusters[target.ClusterName] = resolver
+	if m.clusterAccountIDs == nil {
+		m.clusterAccountIDs = make(map[string]string)
+	}
+	m.clusterAccountIDs[target.ClusterName] = accountID
+	m.mu.Unlock()
+
+	if existed {
+		if err := old.Stop(context.Background()); err != nil {
+			m.logger.Error("failed to stop replaced cluster resolver", zap.String("cluster", target.ClusterName), zap.Error(err))
+		}
+	}
+
+	m.logger.Info("registered remote EKS cluster via assume-role",
+		zap.String("cluster", target.ClusterName), zap.String("account", accountID), zap.String("role", target.AssumeRoleARN))
+	return nil
+}
+
+// accountID returns the AWS account the given cluster was last observed to live in, if it was
+// registered through RegisterRemote. Clusters registered through the plain Register path (the
+// ambient/local cluster) have no known account recorded here.
+func (m *MultiClusterResolver) accountID(clusterName string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.clusterAccountIDs[clusterName]
+	return id, ok
+}
+
+// newAssumedRoleEKSClientset assumes target.AssumeRoleARN, resolves the caller identity's AWS
+// account under that role, and describes target.ClusterName to build a kubernetes.Interface whose
+// transport signs every request with a freshly generated aws-iam-authenticator bearer token. STS
+// credentials are wrapped in stscreds' auto-refreshing provider, so neither the returned clientset
+// nor its informers ever see an expired-credential error: the underlying session simply re-assumes
+// the role the next time credentials are needed after the current ones have expired.
+func newAssumedRoleEKSClientset(target RemoteClusterTarget) (kubernetes.Interface, string, error) {
+	baseSession, err := session.NewSession(&aws.Config{Region: aws.String(target.Region)})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create base AWS session: %w", err)
+	}
+
+	assumedCreds := stscreds.NewCredentials(baseSession, target.AssumeRoleARN, func(p *stscreds.AssumeRoleProvider) {
+		if target.ExternalID != "" {
+			p.ExternalID = aws.String(target.ExternalID)
+		}
+	})
+	assumedSession := baseSession.Copy(&aws.Config{Region: aws.String(target.Region), Credentials: assumedCreds})
+
+	identity, err := sts.New(assumedSession).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve assumed-role identity for %q: %w", target.AssumeRoleARN, err)
+	}
+
+	describeOut, err := eks.New(assumedSession).DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(target.ClusterName)})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to describe cluster %q: %w", target.ClusterName, err)
+	}
+	cluster := describeOut.Cluster
+
+	caData, err := base64.StdEncoding.DecodeString(aws.StringValue(cluster.CertificateAuthority.Data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode cluster CA for %q: %w", target.ClusterName, err)
+	}
+
+	tokenGenerator, err := authtoken.NewGenerator(true, false)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create IAM authenticator token generator: %w", err)
+	}
+
+	restConfig := &rest.Config{
+		Host: aws.StringValue(cluster.Endpoint),
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData: caData,
+		},
+		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+			return &assumedRoleBearerTokenTransport{
+				base:        rt,
+				generator:   tokenGenerator,
+				clusterName: target.ClusterName,
+				session:     assumedSession,
+			}
+		},
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build clientset for %q: %w", target.ClusterName, err)
+	}
+
+	return clientset, aws.StringValue(identity.Account), nil
+}
+
+// assumedRoleBearerTokenTransport signs every outgoing request with a freshly minted
+// aws-iam-authenticator token rather than one computed once at client construction - each token is
+// only valid for about 15 minutes, and a long-lived informer's watch connections span far longer
+// than that, so the token has to be re-derived per round trip off of the (auto-refreshing)
+// assumed-role session.
+type assumedRoleBearerTokenTransport struct {
+	base        http.RoundTripper
+	generator   authtoken.Generator
+	clusterName string
+	session     *session.Session
+}
+
+func (t *assumedRoleBearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.generator.GetWithSTS(t.clusterName, sts.New(t.session))
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh EKS bearer token for %q: %w", t.clusterName, err)
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Header.Set("Authorization", "Bearer "+tok.Token)
+	return t.base.RoundTrip(signed)
+}
@@ -0,0 +1,107 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestExtractIPPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantIP   string
+		wantPort string
+		wantOK   bool
+	}{
+		{
+			name:     "ipv4",
+			input:    "192.0.2.10:8080",
+			wantIP:   "192.0.2.10",
+			wantPort: "8080",
+			wantOK:   true,
+		},
+		{
+			name:     "bracketed ipv6",
+			input:    "[2001:db8::1]:8080",
+			wantIP:   "2001:db8::1",
+			wantPort: "8080",
+			wantOK:   true,
+		},
+		{
+			name:     "ipv4-mapped ipv6",
+			input:    "[::ffff:192.0.2.10]:8080",
+			wantPort: "8080",
+			wantOK:   true,
+		},
+		{
+			name:   "hostname:port is not an IP",
+			input:  "my-service.default.svc:8080",
+			wantOK: false,
+		},
+		{
+			name:   "bare ip with no port",
+			input:  "192.0.2.10",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, port, ok := extractIPPort(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				return
+			}
+			assert.Equal(t, tt.wantPort, port)
+			if tt.wantIP != "" {
+				assert.Equal(t, tt.wantIP, ip)
+			}
+		})
+	}
+}
+
+func TestPodIPsIndexesBothFamilies(t *testing.T) {
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			PodIP: "192.0.2.10",
+			PodIPs: []corev1.PodIP{
+				{IP: "192.0.2.10"},
+				{IP: "2001:db8::10"},
+			},
+		},
+	}
+
+	ips := podIPs(pod)
+	assert.ElementsMatch(t, []string{"192.0.2.10", "2001:db8::10"}, ips)
+}
+
+// TestGetWorkloadAndNamespaceByIPDualStack verifies that a dual-stack pod resolves to the same
+// workload regardless of which address family the client happened to dial - the whole point of
+// indexing every address in podIPs(pod) rather than only pod.Status.PodIP.
+func TestGetWorkloadAndNamespaceByIPDualStack(t *testing.T) {
+	ipToPod := &sync.Map{}
+	podToWorkloadAndNamespace := &sync.Map{}
+
+	ipToPod.Store("192.0.2.10", "my-app-abc123")
+	ipToPod.Store("2001:db8::10", "my-app-abc123")
+	podToWorkloadAndNamespace.Store("my-app-abc123", attachNamespace("my-app", "default"))
+
+	resolver := &eksResolver{
+		ipToPod:                   ipToPod,
+		podToWorkloadAndNamespace: podToWorkloadAndNamespace,
+		telemetry:                 newResolverTelemetry(),
+	}
+
+	for _, ip := range []string{"192.0.2.10", "2001:db8::10"} {
+		workload, namespace, err := resolver.GetWorkloadAndNamespaceByIP(ip)
+		assert.NoError(t, err)
+		assert.Equal(t, "my-app", workload)
+		assert.Equal(t, "default", namespace)
+	}
+}
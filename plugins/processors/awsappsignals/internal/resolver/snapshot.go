@@ -0,0 +1,237 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// snapshotFormatVersion gates loading: a snapshot written by a version of the agent with an
+	// incompatible on-disk layout is discarded rather than partially, incorrectly unmarshaled.
+	snapshotFormatVersion = 1
+
+	defaultSnapshotInterval = 60 * time.Second
+	// snapshotReconcileGrace bounds how long warm-started entries are trusted before they must be
+	// re-observed by the live informers; it needs to comfortably exceed the time a full initial
+	// List/relist of a large cluster's pods can take.
+	snapshotReconcileGrace = 30 * time.Second
+)
+
+// resolverSnapshot is the on-disk representation of the four sync.Maps that make up an
+// eksResolver's IP index, plus the resource version the pod informer had last synced to when the
+// snapshot was taken. It exists purely to shrink the "every remote IP resolves to
+// UnknownRemoteService" window right after an agent restart; it is never the source of truth once
+// the live informers have caught up.
+type resolverSnapshot struct {
+	Version                      int                 `json:"version"`
+	PodResourceVersion           string              `json:"podResourceVersion"`
+	IPToPod                      map[string]string   `json:"ipToPod"`
+	PodToWorkloadAndNamespace    map[string]string   `json:"podToWorkloadAndNamespace"`
+	WorkloadAndNamespaceToLabels map[string][]string `json:"workloadAndNamespaceToLabels"`
+	ServiceToWorkload            map[string]string   `json:"serviceToWorkload"`
+}
+
+// snapshotter periodically serializes an eksResolver's indexes to filePath so a restart can warm
+// start instead of rebuilding them from an empty cache.
+type snapshotter struct {
+	logger   *zap.Logger
+	filePath string
+	interval time.Duration
+}
+
+func newSnapshotter(logger *zap.Logger, filePath string, interval time.Duration) *snapshotter {
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+	return &snapshotter{logger: logger, filePath: filePath, interval: interval}
+}
+
+// resolverIndexes groups the four sync.Maps a snapshot captures/restores. It's the shape both the
+// live eksResolver fields and a warm-started snapshot load are threaded through, independent of
+// eksResolver itself, since warm start happens before the eksResolver struct is assembled.
+type resolverIndexes struct {
+	ipToPod                      *sync.Map
+	podToWorkloadAndNamespace    *sync.Map
+	workloadAndNamespaceToLabels *sync.Map
+	serviceToWorkload            *sync.Map
+}
+
+// start runs until stopCh closes, writing a fresh snapshot every interval. podInformer is only
+// consulted for its LastSyncResourceVersion watermark, not for pod data itself.
+func (s *snapshotter) start(stopCh <-chan struct{}, podInformer cache.SharedIndexInformer, indexes resolverIndexes) {
+	if s.filePath == "" {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.save(podInformer, indexes); err != nil {
+					s.logger.Warn("failed to write resolver snapshot", zap.String("path", s.filePath), zap.Error(err))
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *snapshotter) save(podInformer cache.SharedIndexInformer, indexes resolverIndexes) error {
+	snap := resolverSnapshot{
+		Version:                      snapshotFormatVersion,
+		PodResourceVersion:           podInformer.LastSyncResourceVersion(),
+		IPToPod:                      stringSyncMapToMap(indexes.ipToPod),
+		PodToWorkloadAndNamespace:    stringSyncMapToMap(indexes.podToWorkloadAndNamespace),
+		WorkloadAndNamespaceToLabels: labelsSyncMapToMap(indexes.workloadAndNamespaceToLabels),
+		ServiceToWorkload:            stringSyncMapToMap(indexes.serviceToWorkload),
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resolver snapshot: %w", err)
+	}
+
+	// write-to-temp-then-rename so a crash mid-write never leaves a truncated snapshot for the
+	// next warm start to choke on.
+	tmpPath := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot temp file: %w", err)
+	}
+	return os.Rename(tmpPath, s.filePath)
+}
+
+// load reads and validates a snapshot previously written by save. A missing file, an unreadable
+// file, or one written by an incompatible snapshotFormatVersion all return ok=false rather than an
+// error, since a cold start with no usable snapshot is an entirely ordinary, non-fatal case.
+func (s *snapshotter) load() (resolverSnapshot, bool) {
+	if s.filePath == "" {
+		return resolverSnapshot{}, false
+	}
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Warn("failed to read resolver snapshot, starting cold", zap.String("path", s.filePath), zap.Error(err))
+		}
+		return resolverSnapshot{}, false
+	}
+
+	var snap resolverSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		s.logger.Warn("failed to parse resolver snapshot, starting cold", zap.String("path", s.filePath), zap.Error(err))
+		return resolverSnapshot{}, false
+	}
+
+	if snap.Version != snapshotFormatVersion {
+		s.logger.Info("resolver snapshot is from an incompatible format version, starting cold",
+			zap.Int("snapshotVersion", snap.Version), zap.Int("supportedVersion", snapshotFormatVersion))
+		return resolverSnapshot{}, false
+	}
+
+	return snap, true
+}
+
+// warmStart populates indexes from snap before the informers have listed anything, and returns,
+// for every pod name the snapshot claims to know about, the IPs it was reachable at - so the
+// caller can reconcile both podToWorkloadAndNamespace and ipToPod against the live informer once
+// it syncs.
+func warmStart(indexes resolverIndexes, snap resolverSnapshot) map[string][]string {
+	podIPs := make(map[string][]string, len(snap.PodToWorkloadAndNamespace))
+
+	for ip, pod := range snap.IPToPod {
+		indexes.ipToPod.Store(ip, pod)
+		podIPs[pod] = append(podIPs[pod], ip)
+	}
+	for pod, workloadAndNamespace := range snap.PodToWorkloadAndNamespace {
+		indexes.podToWorkloadAndNamespace.Store(pod, workloadAndNamespace)
+		if _, ok := podIPs[pod]; !ok {
+			podIPs[pod] = nil
+		}
+	}
+	for workloadAndNamespace, labels := range snap.WorkloadAndNamespaceToLabels {
+		indexes.workloadAndNamespaceToLabels.Store(workloadAndNamespace, mapset.NewSet[string](labels...))
+	}
+	for serviceAndNamespace, workload := range snap.ServiceToWorkload {
+		indexes.serviceToWorkload.Store(serviceAndNamespace, workload)
+	}
+
+	return podIPs
+}
+
+// reconcileWarmStart waits snapshotReconcileGrace after the pod informer's initial sync, then
+// deletes every warm-started pod entry - and every IP it was warm-started at - that the live
+// cache never re-observed - a pod that was deleted while the agent was down, for instance - so a
+// restart can never leak a dead pod's entry, or its dead IP, indefinitely.
+func reconcileWarmStart(logger *zap.Logger, podInformer cache.SharedIndexInformer, podToWorkloadAndNamespace, ipToPod *sync.Map, warmStartedPodIPs map[string][]string, stopCh <-chan struct{}) {
+	if len(warmStartedPodIPs) == 0 {
+		return
+	}
+
+	go func() {
+		select {
+		case <-time.After(snapshotReconcileGrace):
+		case <-stopCh:
+			return
+		}
+
+		live := make(map[string]struct{})
+		for _, obj := range podInformer.GetStore().List() {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				live[pod.Name] = struct{}{}
+			}
+		}
+
+		stale := 0
+		staleIPs := 0
+		for podName, ips := range warmStartedPodIPs {
+			if _, ok := live[podName]; ok {
+				continue
+			}
+			podToWorkloadAndNamespace.Delete(podName)
+			stale++
+			for _, ip := range ips {
+				// an IP already re-observed under a live pod keeps its new mapping
+				if ipToPod.CompareAndDelete(ip, podName) {
+					staleIPs++
+				}
+			}
+		}
+
+		if stale > 0 {
+			logger.Info("reconciled warm-started resolver snapshot against live pod cache",
+				zap.Int("staleEntries", stale), zap.Int("staleIPs", staleIPs), zap.Int("warmStartedEntries", len(warmStartedPodIPs)))
+		}
+	}()
+}
+
+func stringSyncMapToMap(m *sync.Map) map[string]string {
+	out := make(map[string]string)
+	m.Range(func(key, value interface{}) bool {
+		out[key.(string)] = value.(string)
+		return true
+	})
+	return out
+}
+
+func labelsSyncMapToMap(m *sync.Map) map[string][]string {
+	out := make(map[string][]string)
+	m.Range(func(key, value interface{}) bool {
+		out[key.(string)] = value.(mapset.Set[string]).ToSlice()
+		return true
+	})
+	return out
+}
@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "awsappsignals.resolver"
+
+// resolverTelemetry holds the self-telemetry instruments shared by every resolver kind (eks, ec2,
+// generic). It's built off the global meter provider rather than a processor.CreateSettings
+// because eksResolver (and its siblings) are constructed through package-level singletons that
+// outlive any single processor instance and aren't handed a CreateSettings of their own.
+type resolverTelemetry struct {
+	lookupDuration metric.Float64Histogram
+	cacheSize      metric.Int64Gauge
+}
+
+// newResolverTelemetry builds the resolver's instruments, leaving an instrument nil if its
+// construction fails - resolution must never fail or panic because of telemetry.
+func newResolverTelemetry() *resolverTelemetry {
+	meter := otel.GetMeterProvider().Meter(meterName)
+
+	// Both instruments are left nil on construction error; recordLookup/recordCacheSize treat a
+	// nil instrument as "telemetry unavailable" and skip recording rather than failing resolution.
+	lookupDuration, err := meter.Float64Histogram(
+		"awsapm.resolver.lookup.duration",
+		metric.WithDescription("Duration of workload/namespace lookups by IP, in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		lookupDuration = nil
+	}
+
+	cacheSize, err := meter.Int64Gauge(
+		"awsapm.resolver.cache.size",
+		metric.WithDescription("Number of entries currently held in the resolver's IP-to-pod index"),
+	)
+	if err != nil {
+		cacheSize = nil
+	}
+
+	return &resolverTelemetry{
+		lookupDuration: lookupDuration,
+		cacheSize:      cacheSize,
+	}
+}
+
+// recordLookup records how long a GetWorkloadAndNamespaceByIP call took against resolverKind
+// (e.g. "eks"), so dashboards can separate resolver-specific latency from the processor's overall
+// processing time.
+func (t *resolverTelemetry) recordLookup(ctx context.Context, resolverKind string, duration time.Duration) {
+	if t == nil || t.lookupDuration == nil {
+		return
+	}
+	t.lookupDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.String("resolver", resolverKind)))
+}
+
+// recordCacheSize records the current size of resolverKind's IP-to-pod index.
+func (t *resolverTelemetry) recordCacheSize(ctx context.Context, resolverKind string, size int64) {
+	if t == nil || t.cacheSize == nil {
+		return
+	}
+	t.cacheSize.Record(ctx, size, metric.WithAttributes(attribute.String("resolver", resolverKind)))
+}
@@ -11,6 +11,8 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/processor/processorhelper"
+
+	"github.com/aws/amazon-cloudwatch-agent/processor/awsapmprocessor/internal/sharedcomponent"
 )
 
 const (
@@ -22,6 +24,11 @@ const (
 
 var consumerCapabilities = consumer.Capabilities{MutatesData: true}
 
+// processors tracks the one awsapmprocessor instance created per component.Config, so a config
+// that wires the same "awsapm" processor ID into more than one pipeline (traces and metrics, say)
+// shares a single resolver cache and rule set rather than building a redundant copy per pipeline.
+var processors = sharedcomponent.NewMap[component.Config, *awsapmprocessor]()
+
 // NewFactory returns a new factory for the aws attributes processor.
 func NewFactory() processor.Factory {
 	return processor.NewFactory(
@@ -29,6 +36,7 @@ func NewFactory() processor.Factory {
 		createDefaultConfig,
 		processor.WithTraces(createTracesProcessor, stability),
 		processor.WithMetrics(createMetricsProcessor, stability),
+		processor.WithLogs(createLogsProcessor, stability),
 	)
 }
 
@@ -54,7 +62,7 @@ func createTracesProcessor(
 		set,
 		cfg,
 		next,
-		ap.processTraces,
+		ap.Unwrap().processTraces,
 		processorhelper.WithCapabilities(consumerCapabilities),
 		processorhelper.WithStart(ap.Start),
 		processorhelper.WithShutdown(ap.Shutdown))
@@ -76,7 +84,29 @@ func createMetricsProcessor(
 		set,
 		cfg,
 		nextMetricsConsumer,
-		ap.processMetrics,
+		ap.Unwrap().processMetrics,
+		processorhelper.WithCapabilities(consumerCapabilities),
+		processorhelper.WithStart(ap.Start),
+		processorhelper.WithShutdown(ap.Shutdown))
+}
+
+func createLogsProcessor(
+	ctx context.Context,
+	set processor.CreateSettings,
+	cfg component.Config,
+	nextLogsConsumer consumer.Logs,
+) (processor.Logs, error) {
+	ap, err := createProcessor(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return processorhelper.NewLogsProcessor(
+		ctx,
+		set,
+		cfg,
+		nextLogsConsumer,
+		ap.Unwrap().processLogs,
 		processorhelper.WithCapabilities(consumerCapabilities),
 		processorhelper.WithStart(ap.Start),
 		processorhelper.WithShutdown(ap.Shutdown))
@@ -85,12 +115,13 @@ func createMetricsProcessor(
 func createProcessor(
 	params processor.CreateSettings,
 	cfg component.Config,
-) (*awsapmprocessor, error) {
+) (*sharedcomponent.Component[*awsapmprocessor], error) {
 	pCfg, ok := cfg.(*Config)
 	if !ok {
 		return nil, errors.New("could not initialize awsapmprocessor")
 	}
-	ap := &awsapmprocessor{logger: params.Logger, config: pCfg}
 
-	return ap, nil
+	return processors.GetOrAdd(cfg, func() *awsapmprocessor {
+		return &awsapmprocessor{logger: params.Logger, config: pCfg}
+	}), nil
 }
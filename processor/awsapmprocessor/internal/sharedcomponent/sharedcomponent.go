@@ -0,0 +1,79 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+// Package sharedcomponent exposes a way to share one instance of a component across multiple
+// pipelines, modeled on the identically named internal package in the upstream OpenTelemetry
+// Collector Contrib factories. awsapmprocessor needs this because its traces/metrics/logs
+// processors each carry their own resolver cache and compiled drop/keep rules: without it, a
+// config that wires the same "awsapm" processor ID into more than one pipeline would stand up a
+// redundant copy of all of that per pipeline instead of sharing one.
+package sharedcomponent
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Map tracks one *Component[V] per K, handing back the existing instance instead of creating a
+// new one when the same key is requested again.
+type Map[K comparable, V component.Component] struct {
+	lock       sync.Mutex
+	components map[K]*Component[V]
+}
+
+// NewMap returns an empty Map.
+func NewMap[K comparable, V component.Component]() *Map[K, V] {
+	return &Map[K, V]{
+		components: map[K]*Component[V]{},
+	}
+}
+
+// GetOrAdd returns the Component previously registered under key, or creates one via create and
+// registers it if key hasn't been seen before.
+func (m *Map[K, V]) GetOrAdd(key K, create func() V) *Component[V] {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if comp, ok := m.components[key]; ok {
+		return comp
+	}
+
+	comp := &Component[V]{component: create()}
+	m.components[key] = comp
+	return comp
+}
+
+// Component wraps a component.Component so that, no matter how many pipelines hold a reference to
+// it, Start runs at most once and Shutdown runs at most once - each pipeline's processorhelper
+// calls both independently, and the underlying component has no way to know it's shared.
+type Component[V component.Component] struct {
+	component V
+
+	startOnce sync.Once
+	startErr  error
+	stopOnce  sync.Once
+	stopErr   error
+}
+
+// Unwrap returns the wrapped component.
+func (c *Component[V]) Unwrap() V {
+	return c.component
+}
+
+// Start implements component.Component.
+func (c *Component[V]) Start(ctx context.Context, host component.Host) error {
+	c.startOnce.Do(func() {
+		c.startErr = c.component.Start(ctx, host)
+	})
+	return c.startErr
+}
+
+// Shutdown implements component.Component.
+func (c *Component[V]) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		c.stopErr = c.component.Shutdown(ctx)
+	})
+	return c.stopErr
+}
@@ -0,0 +1,56 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT
+
+package awsapmprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// processLogs mirrors processTraces/processMetrics for the logs pipeline: every log record is run
+// through this processor's resolvers (to attribute AttributeRemoteService/AttributeRemoteNamespace)
+// and, if configured, its customconfiguration dropper, so a rule or resolver configured once
+// applies uniformly across traces, metrics, and logs.
+func (ap *awsapmprocessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		resource := rl.Resource()
+
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			sl := sls.At(j)
+			scope := sl.Scope()
+
+			sl.LogRecords().RemoveIf(func(record plog.LogRecord) bool {
+				return ap.processLogRecord(record, scope, resource)
+			})
+		}
+	}
+	return ld, nil
+}
+
+// processLogRecord runs record through every configured resolver and, if a dropper is configured,
+// returns true when the record matched a drop rule and should be removed from the batch.
+func (ap *awsapmprocessor) processLogRecord(record plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) bool {
+	for _, resolver := range ap.resolvers {
+		if err := resolver.Process(record.Attributes(), resource.Attributes()); err != nil {
+			ap.logger.Debug("failed to resolve log record attributes", zap.Error(err))
+		}
+	}
+
+	if ap.dropper == nil {
+		return false
+	}
+
+	dropped, _, err := ap.dropper.ShouldBeDroppedLogRecord(record, scope, resource)
+	if err != nil {
+		ap.logger.Debug("failed to evaluate drop rules for log record", zap.Error(err))
+		return false
+	}
+	return dropped
+}